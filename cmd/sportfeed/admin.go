@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+)
+
+// adminFlags registers the flags shared by the admin commands.
+func adminFlags(fs *flag.FlagSet, opts *config.Options) (dryRun *bool) {
+	opts.RegisterFlags(fs)
+
+	return fs.Bool("dry-run", false, "only report what is missing, without creating it")
+}
+
+// printResources prints the state of each resource, one per line.
+func printResources(resources ...service.Resource) {
+	for _, resource := range resources {
+		fmt.Println(resource)
+	}
+}
+
+// runAdminTopics creates the topics that the producer and consumer use, named for the profile.
+func runAdminTopics(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	dryRun := adminFlags(fs, &opts)
+	partitions := fs.Int("partitions", 6, "number of partitions of the created topics")
+	replicationFactor := fs.Int("replication-factor", -1, "replication factor of the created topics, -1 for the broker default")
+	parse(fs, args)
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	resources, err := service.EnsureTopics(ctx, cfg, *partitions, *replicationFactor, *dryRun)
+	printResources(resources...)
+
+	return err
+}
+
+// runAdminTables creates the table named by consumer.dynamodb.table.
+func runAdminTables(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	dryRun := adminFlags(fs, &opts)
+	parse(fs, args)
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	resource, err := service.EnsureTable(ctx, cfg, *dryRun)
+	printResources(resource)
+
+	return err
+}
+
+// runAdminIndices creates the index named by consumer.elasticsearch.index.
+func runAdminIndices(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	dryRun := adminFlags(fs, &opts)
+	parse(fs, args)
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	resource, err := service.EnsureIndex(ctx, cfg, *dryRun)
+	printResources(resource)
+
+	return err
+}
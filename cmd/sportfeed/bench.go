@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/codec"
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// runBenchProducer produces the same number of football matches with each producer profile
+// and reports throughput and delivery latency.
+func runBenchProducer(c *command, args []string) error {
+	profiles := maps.Keys(config.ProducerProfiles)
+	slices.Sort(profiles)
+
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+
+	messages := fs.Int("messages", 10000, "number of messages to produce per producer profile")
+	topic := fs.String("topic", "", "topic to produce to (default "+sports.TopicNewFootballMatch+"-bench in the profile)")
+	profileList := fs.String("producer-profiles", strings.Join(profiles, ","), "comma-separated producer profiles to benchmark")
+	parse(fs, args)
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	if *topic == "" {
+		*topic = cfg.Topics.Name(sports.TopicNewFootballMatch + "-bench")
+	}
+
+	codecs, err := service.NewTopicCodecs(cfg, sports.TopicNewFootballMatch)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "profile\tmessages\tfailed\tmsg/s\tMB/s\tp50\tp99\tmax")
+
+	for _, profile := range strings.Split(*profileList, ",") {
+		kafkaConfigMap, err := config.WithProducerProfile(cfg.KafkaConfigMap, profile)
+		if err != nil {
+			return err
+		}
+
+		result, err := benchmarkProfile(kafkaConfigMap, codecs[sports.TopicNewFootballMatch], *topic, *messages)
+		if err != nil {
+			return errors.New("Failed to benchmark profile " + profile + ": " + err.Error())
+		}
+
+		fmt.Fprintf(w, "%s\t%d\t%d\t%.0f\t%.2f\t%s\t%s\t%s\n",
+			profile, *messages, result.failed,
+			float64(*messages)/result.elapsed.Seconds(),
+			float64(result.bytes)/result.elapsed.Seconds()/1e6,
+			result.percentile(0.50), result.percentile(0.99), result.percentile(1))
+	}
+
+	return w.Flush()
+}
+
+type benchResult struct {
+	elapsed   time.Duration
+	bytes     int
+	failed    int
+	latencies []time.Duration
+}
+
+func (r *benchResult) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	return r.latencies[int(p*float64(len(r.latencies)-1))]
+}
+
+func benchmarkProfile(kafkaConfigMap *kafka.ConfigMap, c codec.Codec, topic string, messages int) (*benchResult, error) {
+	producer, err := kafka.NewProducer(kafkaConfigMap)
+	if err != nil {
+		return nil, err
+	}
+	defer producer.Close()
+
+	result := &benchResult{latencies: make([]time.Duration, 0, messages)}
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for e := range producer.Events() {
+			if ev, ok := e.(*kafka.Message); ok {
+				if ev.TopicPartition.Error != nil {
+					result.failed++
+				} else {
+					result.latencies = append(result.latencies, time.Since(ev.Opaque.(time.Time)))
+				}
+
+				if len(result.latencies)+result.failed == messages {
+					return
+				}
+			}
+		}
+	}()
+
+	start := time.Now()
+
+	for i := 0; i < messages; i++ {
+		footballMatch := sports.NewFootballMatch()
+
+		value, err := c.Marshal(topic, footballMatch)
+		if err != nil {
+			return nil, err
+		}
+
+		result.bytes += len(value)
+
+		msg := &kafka.Message{
+			TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+			Key:            []byte(footballMatch.ID.String()),
+			Value:          value,
+			Opaque:         time.Now(),
+		}
+
+		for {
+			err := producer.Produce(msg, nil)
+
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && kafkaErr.Code() == kafka.ErrQueueFull {
+				// wait for the local queue to drain before retrying
+				producer.Flush(100)
+
+				continue
+			}
+
+			if err != nil {
+				return nil, err
+			}
+
+			break
+		}
+	}
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Minute):
+		return nil, errors.New("timed out waiting for delivery reports")
+	}
+
+	result.elapsed = time.Since(start)
+
+	slices.Sort(result.latencies)
+
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+)
+
+// runConfigCheck loads the config from the same sources as produce and consume
+// and reports every missing, malformed or conflicting setting at once.
+// Run it with -config deploy/config.example.toml -allow-placeholders to check that the example still loads and validates.
+func runConfigCheck(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+
+	allowPlaceholders := fs.Bool("allow-placeholders", false, `accept the "BYO ..." placeholder values of the example config`)
+	parse(fs, args)
+
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return err
+	}
+
+	if err := cfg.Validate(config.ValidateOptions{AllowPlaceholders: *allowPlaceholders, Topics: service.TopicSinks()}); err != nil {
+		return err
+	}
+
+	fmt.Println("config is valid")
+
+	return nil
+}
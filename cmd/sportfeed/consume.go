@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// runConsume writes football matches to the sinks of their topic until interrupted.
+// Live settings are applied when the config file changes.
+func runConsume(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+	parse(fs, args)
+
+	logger := slog.Default()
+
+	validate := config.ValidateOptions{Topics: service.TopicSinks()}
+
+	cfg, err := loadConfig(opts, &validate)
+	if err != nil {
+		return err
+	}
+
+	sdc, err := service.NewSportDataConsumer(cfg, logger)
+	if err != nil {
+		return errors.New("Failed to create SportDataConsumer: " + err.Error())
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	supervisor := service.NewSupervisor(logger)
+	supervisor.Add("consumer", sdc.Run)
+
+	watcher := config.NewWatcher(opts, cfg, validate, logger)
+	watcher.Subscribe(func(change config.Change) {
+		slog.SetLogLoggerLevel(change.New.LogLevel)
+		sdc.ApplyConfig(change.New.ConsumerConfig)
+	})
+	supervisor.Add("config watcher", watcher.Run)
+
+	return supervisor.Run(ctx)
+}
+
+// runReplay consumes football matches again with a consumer group of its own, so the sinks catch up on matches
+// they missed or lost without moving the offsets of the consume command. Sinks only replace a stored match
+// with a newer version of it, so replaying matches they already hold changes nothing.
+func runReplay(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+
+	from := newStartFlag("earliest")
+	fs.Var(from, "from", "replay the matches produced since `time`: earliest, an RFC 3339 time or a duration ago such as 2h")
+	group := fs.String("group", "", "consumer group of the replay, which resumes where an earlier replay of the group stopped (default <kafka.consumer.group.id>-replay)")
+	sinks := fs.String("sinks", "", "comma-separated sinks to write to (default the sinks of "+sports.TopicNewFootballMatch+" in the config)")
+	idle := fs.Duration("idle", 30*time.Second, "stop once no message has arrived for this long, 0 to run until interrupted")
+	parse(fs, args)
+
+	if from.reset == "latest" {
+		return errors.New("-from latest would replay nothing, use earliest, a time or a duration")
+	}
+
+	logger := slog.Default()
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	// only matches are replayed, events are archived once and never change
+	matchSinks := cfg.ConsumerConfig.Sinks[sports.TopicNewFootballMatch]
+	if *sinks != "" {
+		matchSinks = strings.Split(*sinks, ",")
+	}
+
+	cfg.ConsumerConfig.Sinks = map[string][]string{sports.TopicNewFootballMatch: matchSinks}
+
+	if *group == "" {
+		*group = fmt.Sprintf("%v-replay", (*cfg.KafkaConsumerConfigMap)["group.id"])
+	}
+
+	(*cfg.KafkaConsumerConfigMap)["group.id"] = *group
+	(*cfg.KafkaConsumerConfigMap)["auto.offset.reset"] = from.reset
+
+	if err := cfg.Validate(config.ValidateOptions{Topics: service.TopicSinks()}); err != nil {
+		return err
+	}
+
+	sdc, err := service.NewSportDataConsumer(cfg, logger)
+	if err != nil {
+		return errors.New("Failed to create SportDataConsumer: " + err.Error())
+	}
+
+	sdc.StartAt = from.at
+	sdc.IdleTimeout = *idle
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	logger.Info(fmt.Sprintf("Replaying %s from %s with consumer group %s",
+		cfg.Topics.Name(sports.TopicNewFootballMatch), from, *group))
+
+	return sdc.Run(ctx)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// dlqOptions are the flags shared by the dlq commands.
+type dlqOptions struct {
+	config config.Options
+	topic  string
+	limit  int
+	idle   time.Duration
+}
+
+// dlqFlags registers the flags shared by the dlq commands.
+func dlqFlags(fs *flag.FlagSet) *dlqOptions {
+	opts := new(dlqOptions)
+
+	opts.config.RegisterFlags(fs)
+	fs.StringVar(&opts.topic, "topic", "", "dead-letter topic (default the dead-letter topic of "+sports.TopicNewFootballMatch+" in the profile)")
+	fs.IntVar(&opts.limit, "limit", 0, "maximum number of messages to process, 0 for all")
+	fs.DurationVar(&opts.idle, "idle", 10*time.Second, "stop after no message has arrived for this long")
+
+	return opts
+}
+
+// load loads the config and resolves the default dead-letter topic in it.
+func (opts *dlqOptions) load() (*config.Config, error) {
+	cfg, err := loadConfig(opts.config, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.topic == "" {
+		opts.topic = cfg.Topics.Name(service.DeadLetterTopic(sports.TopicNewFootballMatch))
+	}
+
+	return cfg, nil
+}
+
+// runDLQInspect prints the messages of a dead-letter topic and their headers.
+func runDLQInspect(c *command, args []string) error {
+	fs := c.flags()
+	opts := dlqFlags(fs)
+	parse(fs, args)
+
+	cfg, err := opts.load()
+	if err != nil {
+		return err
+	}
+
+	return inspectDeadLetters(cfg, opts.topic, opts.limit, opts.idle)
+}
+
+// runDLQRedrive re-drives the messages of a dead-letter topic once the cause of their failure is fixed.
+func runDLQRedrive(c *command, args []string) error {
+	fs := c.flags()
+	opts := dlqFlags(fs)
+	parse(fs, args)
+
+	cfg, err := opts.load()
+	if err != nil {
+		return err
+	}
+
+	return redriveDeadLetters(cfg, slog.Default(), opts.topic, opts.limit, opts.idle)
+}
+
+// inspectDeadLetters reads the dead-letter topic from the beginning with a throwaway consumer group.
+func inspectDeadLetters(cfg *config.Config, topic string, limit int, idle time.Duration) error {
+	(*cfg.KafkaConsumerConfigMap)["group.id"] = "dlq-inspect-" + uuid.NewString()
+	(*cfg.KafkaConsumerConfigMap)["auto.offset.reset"] = "earliest"
+
+	return readDeadLetters(cfg, topic, limit, idle, func(_ *kafka.Consumer, msg *kafka.Message) error {
+		fmt.Printf("%s [%d] @ %s key = %s\n", topic, msg.TopicPartition.Partition, msg.TopicPartition.Offset, msg.Key)
+
+		for _, header := range msg.Headers {
+			fmt.Printf("  %s: %s\n", header.Key, header.Value)
+		}
+
+		fmt.Printf("  value: %q\n\n", msg.Value)
+
+		return nil
+	})
+}
+
+// redriveDeadLetters produces every dead-lettered message back to its original topic,
+// committing its offset in the dead-letter topic only once the original topic has accepted it.
+func redriveDeadLetters(cfg *config.Config, logger *slog.Logger, topic string, limit int, idle time.Duration) error {
+	(*cfg.KafkaConsumerConfigMap)["group.id"] = fmt.Sprintf("%s-redrive", (*cfg.KafkaConsumerConfigMap)["group.id"])
+	(*cfg.KafkaConsumerConfigMap)["auto.offset.reset"] = "earliest"
+
+	producer, err := kafka.NewProducer(cfg.KafkaProducerConfigMap)
+	if err != nil {
+		return errors.New("Failed to create Producer: " + err.Error())
+	}
+	defer producer.Close()
+
+	redriven := 0
+
+	err = readDeadLetters(cfg, topic, limit, idle, func(consumer *kafka.Consumer, msg *kafka.Message) error {
+		original, err := service.NewRedriveMessage(msg)
+		if err != nil {
+			return fmt.Errorf("Failed to redrive offset %s: %w", msg.TopicPartition.Offset, err)
+		}
+
+		if _, err := service.PublishSync(context.Background(), producer, original); err != nil {
+			return fmt.Errorf("Failed to redrive offset %s: %w", msg.TopicPartition.Offset, err)
+		}
+
+		if _, err := consumer.CommitMessage(msg); err != nil {
+			return errors.New("Failed to commit redriven message: " + err.Error())
+		}
+
+		redriven++
+
+		return nil
+	})
+
+	logger.Info(fmt.Sprintf("Redrove %d messages from %s", redriven, topic))
+
+	return err
+}
+
+func readDeadLetters(
+	cfg *config.Config,
+	topic string,
+	limit int,
+	idle time.Duration,
+	handle func(*kafka.Consumer, *kafka.Message) error,
+) error {
+	consumer, err := kafka.NewConsumer(cfg.KafkaConsumerConfigMap)
+	if err != nil {
+		return errors.New("Failed to create Consumer: " + err.Error())
+	}
+	defer consumer.Close()
+
+	if err := consumer.SubscribeTopics([]string{topic}, nil); err != nil {
+		return errors.New("Failed to subscribe to topic: " + err.Error())
+	}
+
+	for processed := 0; limit == 0 || processed < limit; processed++ {
+		msg, err := consumer.ReadMessage(idle)
+		if err != nil {
+			var kafkaErr kafka.Error
+			if errors.As(err, &kafkaErr) && kafkaErr.IsTimeout() {
+				return nil
+			}
+
+			return errors.New("Error reading message: " + err.Error())
+		}
+
+		if err := handle(consumer, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
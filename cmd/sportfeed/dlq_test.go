@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/tuannkhoi/sport-data-feed/service"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// TestDLQRedrive dead-letters a message on a mock Kafka cluster, redrives it and checks that it is back on its
+// original topic with its own headers but without the dead-letter ones, and that its dead-letter offset is committed.
+func TestDLQRedrive(t *testing.T) {
+	cluster, err := kafka.NewMockCluster(1)
+	if err != nil {
+		t.Fatalf("NewMockCluster() error = %v", err)
+	}
+	defer cluster.Close()
+
+	topic := sports.TopicNewFootballMatch
+	deadLetterTopic := service.DeadLetterTopic(topic)
+
+	for _, name := range []string{topic, deadLetterTopic} {
+		if err := cluster.CreateTopic(name, 1, 1); err != nil {
+			t.Fatalf("CreateTopic(%s) error = %v", name, err)
+		}
+	}
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": cluster.BootstrapServers()})
+	if err != nil {
+		t.Fatalf("NewProducer() error = %v", err)
+	}
+	defer producer.Close()
+
+	msg := &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 0, Offset: 7},
+		Key:            []byte("match-1"),
+		Value:          []byte(`{"id":"match-1"}`),
+		Headers:        []kafka.Header{{Key: "trace-id", Value: []byte("abc")}},
+	}
+
+	dlm := service.NewDeadLetterMessage(msg, deadLetterTopic, errors.New("boom"), 3)
+	if _, err := service.PublishSync(context.Background(), producer, dlm); err != nil {
+		t.Fatalf("Failed to dead-letter the message: %v", err)
+	}
+
+	file := writeConfigFile(t, fmt.Sprintf("[kafka]\nbootstrap.servers = %q\n\n[kafka.consumer]\ngroup.id = \"test\"\n",
+		cluster.BootstrapServers()))
+
+	if code := execute("dlq", "redrive", "-config", file, "-topic", deadLetterTopic, "-limit", "1"); code != 0 {
+		t.Fatalf("dlq redrive exited with %d, want 0", code)
+	}
+
+	consumer, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          "test-check",
+		"auto.offset.reset": "earliest",
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	defer consumer.Close()
+
+	if err := consumer.SubscribeTopics([]string{topic}, nil); err != nil {
+		t.Fatalf("SubscribeTopics() error = %v", err)
+	}
+
+	redriven, err := consumer.ReadMessage(30 * time.Second)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+
+	if string(redriven.Key) != string(msg.Key) || string(redriven.Value) != string(msg.Value) {
+		t.Errorf("redriven key, value = %q, %q, want %q, %q", redriven.Key, redriven.Value, msg.Key, msg.Value)
+	}
+
+	if !slices.EqualFunc(redriven.Headers, msg.Headers, func(a, b kafka.Header) bool {
+		return a.Key == b.Key && string(a.Value) == string(b.Value)
+	}) {
+		t.Errorf("redriven headers = %v, want only the original %v", redriven.Headers, msg.Headers)
+	}
+
+	redriveGroup, err := kafka.NewConsumer(&kafka.ConfigMap{
+		"bootstrap.servers": cluster.BootstrapServers(),
+		"group.id":          "test-redrive",
+	})
+	if err != nil {
+		t.Fatalf("NewConsumer() error = %v", err)
+	}
+	defer redriveGroup.Close()
+
+	committed, err := redriveGroup.Committed([]kafka.TopicPartition{{Topic: &deadLetterTopic, Partition: 0}}, 5000)
+	if err != nil {
+		t.Fatalf("Committed() error = %v", err)
+	}
+
+	if committed[0].Offset != 1 {
+		t.Errorf("committed dead-letter offset = %s, want 1", committed[0].Offset)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+)
+
+// command is a subcommand of sportfeed, or a group of subcommands such as admin.
+type command struct {
+	name    string
+	summary string
+	// run runs a command with the arguments that follow its name. Groups have commands instead.
+	run      func(c *command, args []string) error
+	commands []*command
+
+	// path is the full name of the command, such as "sportfeed admin topics".
+	path string
+}
+
+var commands = []*command{
+	{name: "produce", summary: "produce random football matches, scenarios and matches posted to the ingestion API", run: runProduce},
+	{name: "consume", summary: "write the consumed football matches and events to the configured sinks", run: runConsume},
+	{name: "replay", summary: "write the football matches produced since a given time to the sinks again", run: runReplay},
+	{name: "tail", summary: "print the decoded messages of a topic as JSON lines", run: runTail},
+	{name: "admin", summary: "create the topics, tables and indices of the config", commands: []*command{
+		{name: "topics", summary: "create the missing Kafka topics, including the dead-letter topic", run: runAdminTopics},
+		{name: "tables", summary: "create the DynamoDB table of the dynamodb sink when it is missing", run: runAdminTables},
+		{name: "indices", summary: "create the Elasticsearch index of the elasticsearch sink when it is missing", run: runAdminIndices},
+	}},
+	{name: "config", summary: "inspect the config", commands: []*command{
+		{name: "check", summary: "report every missing, malformed or conflicting setting of the config", run: runConfigCheck},
+	}},
+	{name: "dlq", summary: "inspect and re-drive dead-letter topics", commands: []*command{
+		{name: "inspect", summary: "print the messages in a dead-letter topic without consuming them", run: runDLQInspect},
+		{name: "redrive", summary: "send the messages in a dead-letter topic back to their original topic", run: runDLQRedrive},
+	}},
+	{name: "schema", summary: "check the JSON Schemas of the events", commands: []*command{
+		{name: "check", summary: "check that the event types match their committed JSON Schemas and stay compatible", run: runSchemaCheck},
+	}},
+	{name: "bench", summary: "benchmark the producer profiles", commands: []*command{
+		{name: "producer", summary: "compare the throughput and delivery latency of the producer profiles", run: runBenchProducer},
+	}},
+}
+
+// sportfeed runs the football match producer and consumer and the tools that operate them.
+// Commands that read the config share the -config, -profile and -set flags.
+func main() {
+	root := &command{name: "sportfeed", commands: commands, path: "sportfeed"}
+
+	os.Exit(root.execute(os.Args[1:]))
+}
+
+// execute runs the command, or the subcommand of a group named by the first argument, returning the exit code.
+func (c *command) execute(args []string) int {
+	if c.run != nil {
+		if err := c.run(c, args); err != nil {
+			slog.Error(err.Error())
+
+			return 1
+		}
+
+		return 0
+	}
+
+	if len(args) == 0 {
+		c.usage(os.Stderr)
+
+		return 2
+	}
+
+	switch args[0] {
+	case "help", "-h", "-help", "--help":
+		c.usage(os.Stdout)
+
+		return 0
+	}
+
+	for _, sub := range c.commands {
+		if sub.name == args[0] {
+			sub.path = c.path + " " + sub.name
+
+			return sub.execute(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", c.path+" "+args[0])
+	c.usage(os.Stderr)
+
+	return 2
+}
+
+// usage lists the subcommands of a group.
+func (c *command) usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: %s <command> [flags]\n\ncommands:\n", c.path)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	for _, sub := range c.commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", sub.name, sub.summary)
+	}
+	tw.Flush()
+
+	fmt.Fprintf(w, "\nRun \"%s <command> -h\" for the flags of a command.\n", c.path)
+}
+
+// flags returns the flag set of a command, whose usage message is laid out the same for every command.
+func (c *command) flags() *flag.FlagSet {
+	fs := flag.NewFlagSet(c.path, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s [flags]\n\n%s\n\nflags:\n", c.path, c.summary)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parse parses the flags of a command, which takes no other arguments.
+func parse(fs *flag.FlagSet, args []string) {
+	// the flag set exits on errors by itself
+	_ = fs.Parse(args)
+
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected argument %q\n", fs.Arg(0))
+		fs.Usage()
+
+		os.Exit(2)
+	}
+}
+
+// loadConfig loads the config selected by the -config, -profile and -set flags, validating it unless validate is nil,
+// and applies its log level to the default logger.
+func loadConfig(opts config.Options, validate *config.ValidateOptions) (*config.Config, error) {
+	cfg, err := config.Load(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if validate != nil {
+		if err := cfg.Validate(*validate); err != nil {
+			return nil, err
+		}
+	}
+
+	slog.SetLogLoggerLevel(cfg.LogLevel)
+
+	if cfg.Profile != "" {
+		slog.Info("Using config profile " + cfg.Profile)
+	}
+
+	return cfg, nil
+}
+
+// interruptContext returns a context that is cancelled on SIGINT or SIGTERM, so that commands shut down cleanly.
+func interruptContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// startFlag is where a command starts reading a topic: "earliest", "latest",
+// an RFC 3339 time or a duration ago such as 2h.
+type startFlag struct {
+	value string
+	// reset is the auto.offset.reset that the flag stands for,
+	// and at the time to start from, which is zero for earliest and latest.
+	reset string
+	at    time.Time
+}
+
+func newStartFlag(value string) *startFlag {
+	s := new(startFlag)
+	if err := s.Set(value); err != nil {
+		panic(err)
+	}
+
+	return s
+}
+
+func (s *startFlag) String() string {
+	if s == nil {
+		return ""
+	}
+
+	return s.value
+}
+
+func (s *startFlag) Set(value string) error {
+	switch value {
+	case "earliest", "latest":
+		*s = startFlag{value: value, reset: value}
+
+		return nil
+	}
+
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		*s = startFlag{value: value, reset: "earliest", at: at}
+
+		return nil
+	}
+
+	if ago, err := time.ParseDuration(value); err == nil && ago > 0 {
+		*s = startFlag{value: value, reset: "earliest", at: time.Now().Add(-ago)}
+
+		return nil
+	}
+
+	return errors.New("expected earliest, latest, an RFC 3339 time or a duration such as 2h")
+}
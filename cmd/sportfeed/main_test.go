@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// mainEnv makes the test binary run sportfeed with the arguments after "--" instead of the tests, see runSportfeed.
+const mainEnv = "SPORTFEED_TEST_MAIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(mainEnv) != "" {
+		args := os.Args[slices.Index(os.Args, "--")+1:]
+		os.Args = append([]string{"sportfeed"}, args...)
+		main()
+	}
+
+	os.Exit(m.Run())
+}
+
+// runSportfeed runs sportfeed in a process of its own, for the flag errors that exit the process,
+// and returns its exit code.
+func runSportfeed(t *testing.T, args ...string) int {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^$", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), mainEnv+"=1")
+
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+
+	if err != nil {
+		t.Fatalf("Failed to run sportfeed: %v", err)
+	}
+
+	return 0
+}
+
+// execute runs sportfeed in the test process and returns its exit code.
+func execute(args ...string) int {
+	root := &command{name: "sportfeed", commands: commands, path: "sportfeed"}
+
+	return root.execute(args)
+}
+
+// writeConfigFile writes a config file with the given contents to a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+func TestExecute(t *testing.T) {
+	invalid := writeConfigFile(t, "[consumer]\nfailure_policy = \"retry\"\n")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"no command", nil, 2},
+		{"help", []string{"help"}, 0},
+		{"unknown command", []string{"deploy"}, 2},
+		{"group without a command", []string{"config"}, 2},
+		{"unknown subcommand", []string{"config", "fix"}, 2},
+		{"valid config", []string{"config", "check", "-config", "../../deploy/config.local.toml"}, 0},
+		{"example config", []string{"config", "check", "-config", "../../deploy/config.example.toml", "-allow-placeholders"}, 0},
+		{"example placeholders", []string{"config", "check", "-config", "../../deploy/config.example.toml"}, 1},
+		{"invalid config", []string{"config", "check", "-config", invalid}, 1},
+		{"missing config", []string{"config", "check", "-config", filepath.Join(t.TempDir(), "missing.toml")}, 1},
+		{"overridden setting", []string{"config", "check", "-config", "../../deploy/config.local.toml", "-set", "consumer.max_attempts=0"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := execute(tt.args...); got != tt.want {
+				t.Errorf("sportfeed %s exited with %d, want %d", strings.Join(tt.args, " "), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlagErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"valid flags", []string{"config", "check", "-config", "../../deploy/config.local.toml"}, 0},
+		{"flag help", []string{"config", "check", "-h"}, 0},
+		{"unknown flag", []string{"config", "check", "-verbose"}, 2},
+		{"unexpected argument", []string{"config", "check", "deploy/config.toml"}, 2},
+		{"malformed override", []string{"config", "check", "-set", "consumer.max_attempts"}, 2},
+		{"malformed start", []string{"replay", "-from", "yesterday"}, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := runSportfeed(t, tt.args...); got != tt.want {
+				t.Errorf("sportfeed %s exited with %d, want %d", strings.Join(tt.args, " "), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStartFlag(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value     string
+		wantReset string
+		wantAt    func(time.Time) bool
+	}{
+		{"earliest", "earliest", time.Time.IsZero},
+		{"latest", "latest", time.Time.IsZero},
+		{at.Format(time.RFC3339), "earliest", at.Equal},
+		{"2h", "earliest", func(got time.Time) bool {
+			return time.Since(got).Round(time.Minute) == 2*time.Hour
+		}},
+	}
+
+	for _, tt := range tests {
+		s := new(startFlag)
+
+		if err := s.Set(tt.value); err != nil {
+			t.Errorf("Set(%q) error = %v", tt.value, err)
+
+			continue
+		}
+
+		if s.reset != tt.wantReset || !tt.wantAt(s.at) || s.String() != tt.value {
+			t.Errorf("Set(%q) = %+v, want reset %s", tt.value, *s, tt.wantReset)
+		}
+	}
+
+	for _, value := range []string{"", "yesterday", "-2h", "2024-05-01"} {
+		if err := new(startFlag).Set(value); err == nil {
+			t.Errorf("Set(%q) error = nil, want an error", value)
+		}
+	}
+}
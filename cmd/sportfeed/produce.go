@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/scenario"
+	"github.com/tuannkhoi/sport-data-feed/service"
+)
+
+// runProduce produces a random football match every producer.interval, alongside the configured scenarios
+// and the ingestion API, until interrupted. Live settings are applied when the config file changes.
+func runProduce(c *command, args []string) error {
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+	parse(fs, args)
+
+	logger := slog.Default()
+
+	validate := config.ValidateOptions{Topics: service.TopicSinks()}
+
+	cfg, err := loadConfig(opts, &validate)
+	if err != nil {
+		return err
+	}
+
+	scenarios := make([]*scenario.Scenario, 0, len(cfg.ProducerConfig.Scenarios))
+
+	for _, path := range cfg.ProducerConfig.Scenarios {
+		s, err := scenario.Load(path)
+		if err != nil {
+			return errors.New("Failed to load scenario: " + err.Error())
+		}
+
+		scenarios = append(scenarios, s)
+	}
+
+	sdp, err := service.NewSportDataProducer(cfg, logger)
+	if err != nil {
+		return errors.New("Failed to create SportDataProducer: " + err.Error())
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	supervisor := service.NewSupervisor(logger)
+	supervisor.Add("producer", sdp.Run)
+	supervisor.Add("monitor", sdp.Monitor)
+
+	watcher := config.NewWatcher(opts, cfg, validate, logger)
+	watcher.Subscribe(func(change config.Change) {
+		slog.SetLogLoggerLevel(change.New.LogLevel)
+		sdp.ApplyConfig(change.New.ProducerConfig)
+	})
+	supervisor.Add("config watcher", watcher.Run)
+
+	if cfg.ProducerConfig.HTTPAddress != "" {
+		is := service.NewIngestServer(cfg.ProducerConfig.HTTPAddress, sdp, logger)
+
+		supervisor.Add("ingest", is.Run)
+	}
+
+	for _, s := range scenarios {
+		supervisor.Add("scenario "+s.Name, func(ctx context.Context) error {
+			return sdp.RunScenario(ctx, s)
+		})
+	}
+
+	err = supervisor.Run(ctx)
+
+	if closeErr := sdp.Close(); closeErr != nil {
+		logger.Warn(closeErr.Error())
+	}
+
+	return err
+}
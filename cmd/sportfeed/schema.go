@@ -0,0 +1,34 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+
+	"github.com/tuannkhoi/sport-data-feed/schemas"
+)
+
+// runSchemaCheck fails when an event's Go type no longer matches its committed JSON Schema,
+// or when the current schema version breaks compatibility with an earlier one.
+func runSchemaCheck(c *command, args []string) error {
+	fs := c.flags()
+
+	dir := fs.String("dir", "schemas/json", "directory holding the versioned JSON Schema files")
+	write := fs.Bool("write", false, "regenerate the JSON Schema of the current version of every event")
+	parse(fs, args)
+
+	if *write {
+		if err := schemas.Write(*dir); err != nil {
+			return errors.New("Failed to write JSON schemas: " + err.Error())
+		}
+
+		return nil
+	}
+
+	if err := schemas.Check(*dir); err != nil {
+		return errors.New("Schema compatibility check failed:\n" + err.Error())
+	}
+
+	slog.Info("All event schemas are up to date and compatible")
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/google/uuid"
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/service"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// tailValues creates the value that the messages of each topic are decoded into.
+var tailValues = map[string]func() any{
+	sports.TopicNewFootballMatch:   func() any { return new(sports.FootballMatch) },
+	sports.TopicFootballMatchEvent: func() any { return new(sports.FootballMatchEvent) },
+}
+
+// tailLine is a message printed by tail. Value holds the decoded message, or Error why it could not be decoded.
+type tailLine struct {
+	Topic     string    `json:"topic"`
+	Partition int32     `json:"partition"`
+	Offset    int64     `json:"offset"`
+	Timestamp time.Time `json:"timestamp"`
+	Key       string    `json:"key"`
+	Value     any       `json:"value,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// runTail prints the messages of a topic with a throwaway consumer group that commits nothing,
+// decoded with the encoding of the topic, until interrupted.
+func runTail(c *command, args []string) error {
+	topics := maps.Keys(tailValues)
+	slices.Sort(topics)
+
+	fs := c.flags()
+
+	var opts config.Options
+	opts.RegisterFlags(fs)
+
+	topic := fs.String("topic", sports.TopicNewFootballMatch, "topic to print, one of "+strings.Join(topics, ", ")+", named without the prefix and suffix of the profile")
+	from := newStartFlag("latest")
+	fs.Var(from, "from", "print the messages produced since `time`: earliest, latest, an RFC 3339 time or a duration ago such as 10m")
+	limit := fs.Int("n", 0, "stop after printing this many messages, 0 to run until interrupted")
+	parse(fs, args)
+
+	newValue, ok := tailValues[*topic]
+	if !ok {
+		return fmt.Errorf("cannot tail topic %q, expected one of %s", *topic, strings.Join(topics, ", "))
+	}
+
+	cfg, err := loadConfig(opts, nil)
+	if err != nil {
+		return err
+	}
+
+	codecs, err := service.NewTopicCodecs(cfg, *topic)
+	if err != nil {
+		return err
+	}
+
+	name := cfg.Topics.Name(*topic)
+
+	(*cfg.KafkaConsumerConfigMap)["group.id"] = "sportfeed-tail-" + uuid.NewString()
+	(*cfg.KafkaConsumerConfigMap)["auto.offset.reset"] = from.reset
+	(*cfg.KafkaConsumerConfigMap)["enable.auto.commit"] = false
+
+	consumer, err := kafka.NewConsumer(cfg.KafkaConsumerConfigMap)
+	if err != nil {
+		return errors.New("Failed to create Consumer: " + err.Error())
+	}
+	defer consumer.Close()
+
+	var rebalance kafka.RebalanceCb
+	if !from.at.IsZero() {
+		rebalance = func(consumer *kafka.Consumer, event kafka.Event) error {
+			e, ok := event.(kafka.AssignedPartitions)
+			if !ok {
+				return nil
+			}
+
+			partitions, err := service.StartOffsets(consumer, e.Partitions, from.at)
+			if err != nil {
+				return err
+			}
+
+			return consumer.Assign(partitions)
+		}
+	}
+
+	if err := consumer.SubscribeTopics([]string{name}, rebalance); err != nil {
+		return errors.New("Failed to subscribe to topic: " + err.Error())
+	}
+
+	ctx, stop := interruptContext()
+	defer stop()
+
+	encoder := json.NewEncoder(os.Stdout)
+
+	for printed := 0; (*limit == 0 || printed < *limit) && ctx.Err() == nil; {
+		msg, err := consumer.ReadMessage(100 * time.Millisecond)
+		if err != nil {
+			var kafkaErr kafka.Error
+			switch {
+			case errors.As(err, &kafkaErr) && kafkaErr.IsTimeout():
+			case errors.As(err, &kafkaErr) && kafkaErr.IsFatal():
+				return errors.New("Error reading message: " + err.Error())
+			default:
+				slog.Warn("Error reading message: " + err.Error())
+			}
+
+			continue
+		}
+
+		line := tailLine{
+			Topic:     *msg.TopicPartition.Topic,
+			Partition: msg.TopicPartition.Partition,
+			Offset:    int64(msg.TopicPartition.Offset),
+			Timestamp: msg.Timestamp,
+			Key:       string(msg.Key),
+		}
+
+		value := newValue()
+		if err := codecs[*topic].Unmarshal(name, msg.Value, value); err != nil {
+			line.Error = err.Error()
+		} else {
+			line.Value = value
+		}
+
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+
+		printed++
+	}
+
+	return nil
+}
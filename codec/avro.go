@@ -0,0 +1,180 @@
+package codec
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+//go:embed schemas/football_match.avsc
+var footballMatchAvroSchema string
+
+//go:embed schemas/football_match_event.avsc
+var footballMatchEventAvroSchema string
+
+// avroCodec encodes events as Avro using the schema registry wire format.
+// Schemas are registered under the "<topic>-value" subject, and writer schemas are
+// looked up by the ID embedded in each message and resolved against the reader schema of the event type.
+type avroCodec struct {
+	registry *schemaregistry.Client
+
+	footballMatchSchema      avro.Schema
+	footballMatchEventSchema avro.Schema
+
+	mu              sync.Mutex
+	resolvedSchemas map[resolvedSchemaKey]avro.Schema
+}
+
+// resolvedSchemaKey identifies a writer schema resolved against a reader schema.
+type resolvedSchemaKey struct {
+	id     int
+	reader avro.Schema
+}
+
+func newAvroCodec(registry *schemaregistry.Client) (*avroCodec, error) {
+	if registry == nil {
+		return nil, errors.New("avro encoding requires a schema registry")
+	}
+
+	// parse with private caches, as both schemas define the sports.v1.FootballTeam record
+	footballMatchSchema, err := avro.ParseWithCache(footballMatchAvroSchema, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, errors.New("Failed to parse football match Avro schema: " + err.Error())
+	}
+
+	footballMatchEventSchema, err := avro.ParseWithCache(footballMatchEventAvroSchema, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, errors.New("Failed to parse football match event Avro schema: " + err.Error())
+	}
+
+	return &avroCodec{
+		registry:                 registry,
+		footballMatchSchema:      footballMatchSchema,
+		footballMatchEventSchema: footballMatchEventSchema,
+		resolvedSchemas:          make(map[resolvedSchemaKey]avro.Schema),
+	}, nil
+}
+
+func (ac *avroCodec) Marshal(topic string, v any) ([]byte, error) {
+	var (
+		rawSchema string
+		schema    avro.Schema
+		rec       any
+	)
+
+	switch ev := v.(type) {
+	case *sports.FootballMatch:
+		if ev.HomeTeam == nil || ev.AwayTeam == nil {
+			return nil, errors.New("football match requires a home and an away team")
+		}
+
+		rawSchema, schema, rec = footballMatchAvroSchema, ac.footballMatchSchema, ev.ToAvroRecord()
+	case *sports.FootballMatchEvent:
+		rawSchema, schema, rec = footballMatchEventAvroSchema, ac.footballMatchEventSchema, ev.ToAvroRecord()
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	id, err := ac.registry.Register(context.Background(), topic+"-value", rawSchema)
+	if err != nil {
+		return nil, errors.New("Failed to register Avro schema: " + err.Error())
+	}
+
+	payload, err := avro.Marshal(schema, rec)
+	if err != nil {
+		return nil, err
+	}
+
+	return schemaregistry.Frame(id, payload), nil
+}
+
+func (ac *avroCodec) Unmarshal(_ string, data []byte, v any) error {
+	id, payload, err := schemaregistry.Unframe(data)
+	if err != nil {
+		return err
+	}
+
+	switch ev := v.(type) {
+	case *sports.FootballMatch:
+		schema, err := ac.resolvedSchema(id, ac.footballMatchSchema)
+		if err != nil {
+			return err
+		}
+
+		rec := new(sports.FootballMatchAvroRecord)
+
+		if err := avro.Unmarshal(schema, payload, rec); err != nil {
+			return err
+		}
+
+		fm, err := sports.FootballMatchFromAvroRecord(rec)
+		if err != nil {
+			return err
+		}
+
+		*ev = *fm
+
+		return nil
+	case *sports.FootballMatchEvent:
+		schema, err := ac.resolvedSchema(id, ac.footballMatchEventSchema)
+		if err != nil {
+			return err
+		}
+
+		rec := new(sports.FootballMatchEventAvroRecord)
+
+		if err := avro.Unmarshal(schema, payload, rec); err != nil {
+			return err
+		}
+
+		fme, err := sports.FootballMatchEventFromAvroRecord(rec)
+		if err != nil {
+			return err
+		}
+
+		*ev = *fme
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+// resolvedSchema returns the writer schema with the given ID, resolved against the reader schema.
+func (ac *avroCodec) resolvedSchema(id int, readerSchema avro.Schema) (avro.Schema, error) {
+	key := resolvedSchemaKey{id: id, reader: readerSchema}
+
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+
+	if schema, ok := ac.resolvedSchemas[key]; ok {
+		return schema, nil
+	}
+
+	rawSchema, err := ac.registry.SchemaByID(context.Background(), id)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to look up writer schema %d: %w", id, err)
+	}
+
+	// parse with a private cache so that writer schemas never replace the reader's named types
+	writerSchema, err := avro.ParseWithCache(rawSchema, "", &avro.SchemaCache{})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse writer schema %d: %w", id, err)
+	}
+
+	schema, err := avro.NewSchemaCompatibility().Resolve(readerSchema, writerSchema)
+	if err != nil {
+		return nil, fmt.Errorf("Writer schema %d is incompatible with the reader schema: %w", id, err)
+	}
+
+	ac.resolvedSchemas[key] = schema
+
+	return schema, nil
+}
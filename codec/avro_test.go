@@ -0,0 +1,165 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hamba/avro/v2"
+
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry/schemaregistrytest"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+func newTestAvroCodec(t *testing.T) (Codec, *schemaregistrytest.Server) {
+	t.Helper()
+
+	server := schemaregistrytest.NewServer()
+	t.Cleanup(server.Close)
+
+	c, err := New(Avro, schemaregistry.NewClient(schemaregistry.Config{URL: server.URL}))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return c, server
+}
+
+func TestAvroFootballMatch(t *testing.T) {
+	c, server := newTestAvroCodec(t)
+
+	fm := sports.NewFootballMatch()
+
+	data, err := c.Marshal(sports.TopicNewFootballMatch, fm)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	id, _, err := schemaregistry.Unframe(data)
+	if err != nil {
+		t.Fatalf("Marshal() returned an unframed value: %v", err)
+	}
+
+	if got := server.Subject(sports.TopicNewFootballMatch + "-value"); len(got) != 1 || got[0] != id {
+		t.Errorf("subject has versions %v, want [%d]", got, id)
+	}
+
+	got := new(sports.FootballMatch)
+	if err := c.Unmarshal(sports.TopicNewFootballMatch, data, got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got.ID != fm.ID || *got.HomeTeam != *fm.HomeTeam || *got.AwayTeam != *fm.AwayTeam ||
+		got.Stadium != fm.Stadium || got.Round != fm.Round || got.Competition != fm.Competition ||
+		got.Country != fm.Country || !got.KickOff.Equal(fm.KickOff.Truncate(time.Millisecond)) ||
+		got.Version() != fm.Version() {
+		t.Errorf("Unmarshal() = %+v, want %+v", got, fm)
+	}
+}
+
+func TestAvroFootballMatchEvent(t *testing.T) {
+	c, _ := newTestAvroCodec(t)
+
+	fm := sports.NewFootballMatch()
+
+	goal := sports.NewFootballMatchEvent(fm, sports.FootballMatchEventGoal, 63)
+	goal.Team = fm.AwayTeam
+	goal.Player = "Cole Palmer"
+
+	for _, ev := range []*sports.FootballMatchEvent{goal, sports.NewFootballMatchEvent(fm, sports.FootballMatchEventHalfTime, 45)} {
+		t.Run(string(ev.Type), func(t *testing.T) {
+			data, err := c.Marshal(sports.TopicFootballMatchEvent, ev)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+
+			got := new(sports.FootballMatchEvent)
+			if err := c.Unmarshal(sports.TopicFootballMatchEvent, data, got); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if got.ID != ev.ID || got.MatchID != ev.MatchID || got.Type != ev.Type || got.Minute != ev.Minute ||
+				got.Player != ev.Player || !got.OccurredAt.Equal(ev.OccurredAt.Truncate(time.Millisecond)) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, ev)
+			}
+
+			if (got.Team == nil) != (ev.Team == nil) || got.Team != nil && *got.Team != *ev.Team {
+				t.Errorf("Unmarshal() team = %+v, want %+v", got.Team, ev.Team)
+			}
+		})
+	}
+}
+
+// TestAvroWriterSchema decodes a match written with the first version of the schema,
+// which had no updated_at, as a consumer on a newer build would.
+func TestAvroWriterSchema(t *testing.T) {
+	c, _ := newTestAvroCodec(t)
+
+	const v1 = `{"type":"record","name":"FootballMatch","namespace":"sports.v1","fields":[
+		{"name":"id","type":{"type":"string","logicalType":"uuid"}},
+		{"name":"home_team","type":{"type":"record","name":"FootballTeam","fields":[
+			{"name":"id","type":{"type":"string","logicalType":"uuid"}},
+			{"name":"name","type":"string"},
+			{"name":"stadium","type":"string"}]}},
+		{"name":"away_team","type":"FootballTeam"},
+		{"name":"stadium","type":"string"},
+		{"name":"round","type":"int"},
+		{"name":"competition","type":"string"},
+		{"name":"country","type":"string"},
+		{"name":"kick_off","type":{"type":"long","logicalType":"timestamp-millis"}}]}`
+
+	ac := c.(*avroCodec)
+
+	id, err := ac.registry.Register(context.Background(), sports.TopicNewFootballMatch+"-value", v1)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	v1Schema, err := avro.ParseWithCache(v1, "", &avro.SchemaCache{})
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	fm := sports.NewFootballMatch()
+
+	payload, err := avro.Marshal(v1Schema, fm.ToAvroRecord())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	got := new(sports.FootballMatch)
+	if err := c.Unmarshal(sports.TopicNewFootballMatch, schemaregistry.Frame(id, payload), got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if got.ID != fm.ID || !got.UpdatedAt.IsZero() {
+		t.Errorf("Unmarshal() = %s updated at %s, want %s with no update time", got.ID, got.UpdatedAt, fm.ID)
+	}
+}
+
+func TestAvroNilTeam(t *testing.T) {
+	c, _ := newTestAvroCodec(t)
+
+	fm := sports.NewFootballMatch()
+	fm.AwayTeam = nil
+
+	if _, err := c.Marshal(sports.TopicNewFootballMatch, fm); err == nil {
+		t.Error("Marshal() of a match without an away team succeeded")
+	}
+}
+
+func TestAvroUnsupportedType(t *testing.T) {
+	c, _ := newTestAvroCodec(t)
+
+	if _, err := c.Marshal(sports.TopicNewFootballMatch, uuid.New()); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() error = %v, want ErrUnsupportedType", err)
+	}
+
+	data := schemaregistry.Frame(1, nil)
+	if err := c.Unmarshal(sports.TopicNewFootballMatch, data, new(uuid.UUID)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Unmarshal() error = %v, want ErrUnsupportedType", err)
+	}
+}
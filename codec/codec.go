@@ -0,0 +1,40 @@
+package codec
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
+)
+
+// Supported encodings, as used in the [encoding] section of the config file.
+const (
+	JSON     = "json"
+	Protobuf = "protobuf"
+	Avro     = "avro"
+)
+
+// ErrUnsupportedType is returned when a codec does not know how to encode a value.
+var ErrUnsupportedType = errors.New("unsupported event type")
+
+// Codec converts sports events to Kafka message values and back.
+// The topic is passed through so that codecs can derive per-topic schema information.
+type Codec interface {
+	Marshal(topic string, v any) ([]byte, error)
+	Unmarshal(topic string, data []byte, v any) error
+}
+
+// New returns the codec registered under the given encoding name.
+// The schema registry client is only required by encodings that register their schemas.
+func New(encoding string, registry *schemaregistry.Client) (Codec, error) {
+	switch encoding {
+	case JSON, "":
+		return jsonCodec{}, nil
+	case Protobuf:
+		return protobufCodec{}, nil
+	case Avro:
+		return newAvroCodec(registry)
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+}
@@ -0,0 +1,100 @@
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// benchEncodings are the encodings that need no schema registry.
+var benchEncodings = []string{JSON, Protobuf}
+
+func TestRoundTrip(t *testing.T) {
+	footballMatch := sports.NewFootballMatch()
+
+	for _, encoding := range benchEncodings {
+		c, err := New(encoding, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		payload, err := c.Marshal(sports.TopicNewFootballMatch, footballMatch)
+		if err != nil {
+			t.Fatalf("%s: Marshal() error = %v", encoding, err)
+		}
+
+		got := new(sports.FootballMatch)
+		if err := c.Unmarshal(sports.TopicNewFootballMatch, payload, got); err != nil {
+			t.Fatalf("%s: Unmarshal() error = %v", encoding, err)
+		}
+
+		if got.ID != footballMatch.ID || got.HomeTeam.Name != footballMatch.HomeTeam.Name ||
+			got.Round != footballMatch.Round || !got.KickOff.Equal(footballMatch.KickOff) {
+			t.Errorf("%s: Unmarshal() = %+v, want %+v", encoding, got, footballMatch)
+		}
+
+	}
+
+	// JSON encodes any value, the schema-based encodings only the sports types
+	if _, err := (protobufCodec{}).Marshal(sports.TopicNewFootballMatch, "not a match"); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("Marshal() of a string error = %v, want %v", err, ErrUnsupportedType)
+	}
+
+	if _, err := New("xml", nil); err == nil {
+		t.Error("New() of an unknown encoding error = nil, want an error")
+	}
+}
+
+// BenchmarkMarshal compares the marshal cost and payload size of the encodings.
+func BenchmarkMarshal(b *testing.B) {
+	footballMatch := sports.NewFootballMatch()
+
+	for _, encoding := range benchEncodings {
+		b.Run(encoding, func(b *testing.B) {
+			c, err := New(encoding, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+
+			var payload []byte
+			for i := 0; i < b.N; i++ {
+				if payload, err = c.Marshal(sports.TopicNewFootballMatch, footballMatch); err != nil {
+					b.Fatal(err)
+				}
+			}
+
+			b.ReportMetric(float64(len(payload)), "bytes/msg")
+		})
+	}
+}
+
+// BenchmarkUnmarshal compares the unmarshal cost of the encodings.
+func BenchmarkUnmarshal(b *testing.B) {
+	footballMatch := sports.NewFootballMatch()
+
+	for _, encoding := range benchEncodings {
+		b.Run(encoding, func(b *testing.B) {
+			c, err := New(encoding, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			payload, err := c.Marshal(sports.TopicNewFootballMatch, footballMatch)
+			if err != nil {
+				b.Fatal(err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				if err := c.Unmarshal(sports.TopicNewFootballMatch, payload, new(sports.FootballMatch)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
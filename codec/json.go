@@ -0,0 +1,27 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(_ string, v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal upcasts payloads written with an older schema version before decoding them.
+func (jsonCodec) Unmarshal(_ string, data []byte, v any) error {
+	if _, ok := v.(*sports.FootballMatch); ok {
+		upcasted, err := sports.UpcastFootballMatch(data)
+		if err != nil {
+			return err
+		}
+
+		data = upcasted
+	}
+
+	return json.Unmarshal(data, v)
+}
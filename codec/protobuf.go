@@ -0,0 +1,60 @@
+package codec
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+	"github.com/tuannkhoi/sport-data-feed/sports/sportspb"
+)
+
+type protobufCodec struct{}
+
+func (protobufCodec) Marshal(_ string, v any) ([]byte, error) {
+	switch ev := v.(type) {
+	case *sports.FootballMatch:
+		return proto.Marshal(ev.ToProto())
+	case *sports.FootballMatchEvent:
+		return proto.Marshal(ev.ToProto())
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+func (protobufCodec) Unmarshal(_ string, data []byte, v any) error {
+	switch ev := v.(type) {
+	case *sports.FootballMatch:
+		pb := new(sportspb.FootballMatch)
+
+		if err := proto.Unmarshal(data, pb); err != nil {
+			return err
+		}
+
+		fm, err := sports.FootballMatchFromProto(pb)
+		if err != nil {
+			return err
+		}
+
+		*ev = *fm
+
+		return nil
+	case *sports.FootballMatchEvent:
+		pb := new(sportspb.FootballMatchEvent)
+
+		if err := proto.Unmarshal(data, pb); err != nil {
+			return err
+		}
+
+		fme, err := sports.FootballMatchEventFromProto(pb)
+		if err != nil {
+			return err
+		}
+
+		*ev = *fme
+
+		return nil
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
@@ -2,78 +2,477 @@ package config
 
 import (
 	"context"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/spf13/viper"
+
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
 )
 
 type Config struct {
-	KafkaConfigMap      *kafka.ConfigMap
-	AWSConfig           *aws.Config
-	ElasticsearchConfig *elasticsearch.Config
+	KafkaConfigMap         *kafka.ConfigMap
+	KafkaProducerConfigMap *kafka.ConfigMap
+	KafkaConsumerConfigMap *kafka.ConfigMap
+	AWSConfig              *aws.Config
+	ElasticsearchConfig    *elasticsearch.Config
+	EncodingConfig         *EncodingConfig
+	SchemaRegistryConfig   *schemaregistry.Config
+	ProducerConfig         *ProducerConfig
+	ConsumerConfig         *ConsumerConfig
+	Topics                 *TopicConfig
+	// Profile is the profile that the config was loaded with, empty when none was selected.
+	Profile string
+	// LogLevel is the minimum level of the messages logged by the default logger.
+	LogLevel slog.Level
 }
 
-func init() {
-	viper.AddConfigPath("deploy")
-	viper.SetConfigName("config")
-	viper.SetConfigType("toml")
+// ConsumerConfig holds the settings of the consumer binary itself.
+type ConsumerConfig struct {
+	// Sinks lists the names of the sinks that the messages of each topic are written to.
+	Sinks map[string][]string
+	// FailurePolicy decides what happens to a message that a sink failed to write:
+	// "block" retries it until it succeeds, "dead-letter" retries it up to MaxAttempts times
+	// before sending it to the dead-letter topic, and "skip" logs it and moves on.
+	FailurePolicy string
+	MaxAttempts   int
+	// Offsets are committed once CommitBatchSize messages have been processed or CommitInterval has passed.
+	CommitBatchSize int
+	CommitInterval  time.Duration
+	// PartitionQueueSize is the number of fetched messages queued for each partition before it is paused.
+	PartitionQueueSize int
+	// ShutdownTimeout bounds the time spent draining sink batches and committing offsets on shutdown.
+	ShutdownTimeout time.Duration
+	// Sink writes that fail with a transient error are retried up to RetryMaxAttempts times,
+	// with jittered exponential backoff between RetryInitialBackoff and RetryMaxBackoff.
+	RetryMaxAttempts    int
+	RetryInitialBackoff time.Duration
+	RetryMaxBackoff     time.Duration
+	// A sink's circuit breaker opens after BreakerFailureThreshold consecutive failed writes,
+	// pausing consumption for BreakerOpenTimeout before the sink is tried again.
+	BreakerFailureThreshold int
+	BreakerOpenTimeout      time.Duration
+	// Matches are written to DynamoDBTable in batches of up to DynamoDBBatchSize items (at most 100),
+	// flushed at least every DynamoDBFlushInterval. Offsets are only committed once their batch is written.
+	// DynamoDBEndpoint points the client at DynamoDB Local or LocalStack instead of AWS.
+	DynamoDBTable         string
+	DynamoDBEndpoint      string
+	DynamoDBBatchSize     int
+	DynamoDBFlushInterval time.Duration
+	// Matches are indexed into ElasticsearchIndex with the _bulk API, once ElasticsearchBatchSize documents
+	// or ElasticsearchBatchBytes bytes are buffered, or at least every ElasticsearchFlushInterval.
+	ElasticsearchIndex         string
+	ElasticsearchBatchSize     int
+	ElasticsearchBatchBytes    int
+	ElasticsearchFlushInterval time.Duration
+	// Matches are upserted into the PostgresURL database in batches of PostgresBatchSize, flushed at least
+	// every PostgresFlushInterval. Pending schema migrations are applied on start unless PostgresMigrate is false.
+	PostgresURL           string
+	PostgresBatchSize     int
+	PostgresFlushInterval time.Duration
+	PostgresMigrate       bool
+	// Records are archived as Parquet files in ArchiveBucket, or in ArchiveDir when no bucket is set, under ArchivePrefix.
+	// Open files are staged in ArchiveStagingDir and roll over at ArchiveMaxFileBytes or ArchiveMaxFileAge.
+	ArchiveBucket       string
+	ArchiveEndpoint     string
+	ArchiveDir          string
+	ArchivePrefix       string
+	ArchiveStagingDir   string
+	ArchiveMaxFileBytes int64
+	ArchiveMaxFileAge   time.Duration
+}
 
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatalln(fmt.Sprintf("Failed to read config file: %s", err))
-	}
+// ProducerConfig holds the settings of the producer binary itself.
+type ProducerConfig struct {
+	// A random match is produced every Interval, in one of Leagues or in any league when it is empty.
+	Interval time.Duration
+	Leagues  []string
+	// HTTPAddress is the listen address of the ingestion API, which is disabled when empty.
+	HTTPAddress string
+	// Scenarios are scenario files run alongside the random match generator.
+	Scenarios []string
+}
+
+// TopicConfig names the Kafka topics of an environment. The topic names used throughout the code, such as
+// sports.TopicNewFootballMatch, are wrapped in Prefix and Suffix, so that environments can share a cluster.
+type TopicConfig struct {
+	Prefix string
+	Suffix string
+}
+
+// EncodingConfig selects the wire format used for each Kafka topic.
+type EncodingConfig struct {
+	Default string
+	Topics  map[string]string
+}
+
+// profilesSection holds a section of settings for each profile, such as [profiles.staging].
+const profilesSection = "profiles."
+
+// DefaultFile is the config file read when Options.File is empty and SPORTFEED_CONFIG is not set.
+const DefaultFile = "deploy/config.toml"
+
+// EnvPrefix prefixes the environment variables that override config keys: a key's variable is its name
+// in upper case with dots replaced by underscores, such as SPORTFEED_KAFKA_BOOTSTRAP_SERVERS for kafka.bootstrap.servers.
+const EnvPrefix = "SPORTFEED"
+
+// Options selects the sources that Load reads the config from.
+type Options struct {
+	// File is the path of the TOML config file. When empty, the file named by SPORTFEED_CONFIG is read,
+	// or else DefaultFile if it exists.
+	File string
+	// Profile names the [profiles.<name>] section of the config file that is layered on top of the rest of the file.
+	// When empty, the profile named by SPORTFEED_PROFILE is used, if any.
+	Profile string
+	// Overrides are key=value pairs, such as kafka.bootstrap.servers=localhost:9092, that take precedence over all other sources.
+	Overrides []string
 }
 
-func NewConfig() *Config {
+// RegisterFlags registers the -config, -profile and -set flags on fs, which fill in File, Profile and Overrides.
+func (opts *Options) RegisterFlags(fs *flag.FlagSet) {
+	fs.StringVar(&opts.File, "config", opts.File, "path of the config file (default $"+EnvPrefix+"_CONFIG or "+DefaultFile+")")
+	fs.StringVar(&opts.Profile, "profile", opts.Profile, "config profile to apply, such as staging (default $"+EnvPrefix+"_PROFILE)")
+	fs.Func("set", "override a config key, as key=value (repeatable)", func(value string) error {
+		if !strings.Contains(value, "=") {
+			return fmt.Errorf("expected key=value, got %q", value)
+		}
+
+		opts.Overrides = append(opts.Overrides, value)
+
+		return nil
+	})
+}
+
+// Load reads the config from its layered sources, each taking precedence over the ones before it:
+// defaults, the config file, the selected profile, environment variables and the overrides of opts.
+func Load(opts Options) (*Config, error) {
+	v := viper.New()
+	v.SetConfigType("toml")
+
+	if file, ok := opts.configFile(); ok {
+		v.SetConfigFile(file)
+
+		if err := v.ReadInConfig(); err != nil {
+			return nil, fmt.Errorf("Failed to read config file: %w", err)
+		}
+	}
+
+	profile := opts.profile()
+	if profile != "" {
+		if err := applyProfile(v, profile); err != nil {
+			return nil, err
+		}
+	}
+
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	bindKafkaEnv(v)
+
+	for _, override := range opts.Overrides {
+		key, value, _ := strings.Cut(override, "=")
+		v.Set(strings.ToLower(strings.TrimSpace(key)), value)
+	}
+
+	if err := resolveSecrets(v); err != nil {
+		return nil, fmt.Errorf("Failed to resolve secrets: %w", err)
+	}
+
+	kafkaConfigMap := readKafkaConfig(v)
+
+	kafkaProducerConfigMap, err := readKafkaProducerConfig(v, kafkaConfigMap)
+	if err != nil {
+		return nil, err
+	}
+
+	v.SetDefault("log.level", "info")
+
+	var logLevel slog.Level
+	if err := logLevel.UnmarshalText([]byte(v.GetString("log.level"))); err != nil {
+		return nil, fmt.Errorf("Invalid log.level: %w", err)
+	}
+
+	awsConfig, err := readAWSConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
+	elasticsearchConfig, err := readElasticsearchConfig(v)
+	if err != nil {
+		return nil, err
+	}
+
 	return &Config{
-		KafkaConfigMap:      readKafkaConfig(),
-		AWSConfig:           readAWSConfig(),
-		ElasticsearchConfig: readElasticsearchConfig(),
+		KafkaConfigMap:         kafkaConfigMap,
+		KafkaProducerConfigMap: kafkaProducerConfigMap,
+		KafkaConsumerConfigMap: readKafkaConsumerConfig(v, kafkaConfigMap),
+		AWSConfig:              awsConfig,
+		ElasticsearchConfig:    elasticsearchConfig,
+		EncodingConfig:         readEncodingConfig(v),
+		SchemaRegistryConfig:   readSchemaRegistryConfig(v),
+		ProducerConfig:         readProducerConfig(v),
+		ConsumerConfig:         readConsumerConfig(v),
+		Topics:                 readTopicConfig(v),
+		Profile:                profile,
+		LogLevel:               logLevel,
+	}, nil
+}
+
+// configFile returns the config file that Load reads, and whether there is one to read.
+// A missing file is only an error when it was chosen explicitly.
+func (opts Options) configFile() (string, bool) {
+	file := opts.File
+	if file == "" {
+		file = os.Getenv(EnvPrefix + "_CONFIG")
+	}
+
+	if file != "" {
+		return file, true
+	}
+
+	if _, err := os.Stat(DefaultFile); err != nil {
+		return "", false
+	}
+
+	return DefaultFile, true
+}
+
+// profile returns the name of the profile that Load applies, empty for none.
+func (opts Options) profile() string {
+	if opts.Profile != "" {
+		return opts.Profile
+	}
+
+	return os.Getenv(EnvPrefix + "_PROFILE")
+}
+
+// applyProfile merges the settings of the [profiles.<name>] section over those of the rest of the config file,
+// so a profile only needs the settings that differ between environments, such as kafka.bootstrap.servers,
+// topics.prefix, consumer.dynamodb.table and consumer.elasticsearch.index.
+func applyProfile(v *viper.Viper, name string) error {
+	sub := v.Sub(profilesSection + name)
+	if sub == nil {
+		return fmt.Errorf("Unknown profile %q: the config file has no [%s%s] section", name, profilesSection, name)
+	}
+
+	if err := v.MergeConfigMap(sub.AllSettings()); err != nil {
+		return fmt.Errorf("Failed to apply profile %q: %w", name, err)
+	}
+
+	return nil
+}
+
+// bindKafkaEnv makes Kafka settings that appear in no other source visible to readKafkaConfig,
+// which passes through every key it finds. librdkafka property names use dots and never underscores,
+// so SPORTFEED_KAFKA_SASL_PASSWORD is taken to be kafka.sasl.password.
+func bindKafkaEnv(v *viper.Viper) {
+	prefix := EnvPrefix + "_KAFKA_"
+	keys := v.AllKeys()
+
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		key := "kafka." + strings.ReplaceAll(strings.ToLower(strings.TrimPrefix(name, prefix)), "_", ".")
+		if !slices.Contains(keys, key) {
+			// the default only registers the key, AutomaticEnv still reads its value from the environment
+			v.SetDefault(key, "")
+		}
+	}
+}
+
+// readAWSConfig uses the static keys of the config file when they are set,
+// and otherwise the default credential chain: environment, shared profile, web identity and instance metadata.
+func readAWSConfig(v *viper.Viper) (*aws.Config, error) {
+	region := v.GetString("aws.region")
+
+	accessKeyID := v.GetString("aws.access_key_id")
+	if accessKeyID != "" {
+		return &aws.Config{
+			Region: region,
+			Credentials: credentials.NewStaticCredentialsProvider(
+				accessKeyID, v.GetString("aws.secret_access_key"), v.GetString("aws.session_token")),
+		}, nil
+	}
+
+	awsConfig, err := awsconfig.LoadDefaultConfig(context.Background(), awsconfig.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load AWS config: %w", err)
+	}
+
+	return &awsConfig, nil
+}
+
+// readElasticsearchConfig connects to Elastic Cloud by cloud_id, or else to the listed addresses,
+// authenticating with an API key or basic auth and trusting the CA certificate in ca_file, if any.
+func readElasticsearchConfig(v *viper.Viper) (*elasticsearch.Config, error) {
+	esConfig := &elasticsearch.Config{
+		CloudID:   v.GetString("elasticsearch.cloud_id"),
+		Addresses: v.GetStringSlice("elasticsearch.addresses"),
+		APIKey:    v.GetString("elasticsearch.api_key"),
+		Username:  v.GetString("elasticsearch.username"),
+		Password:  v.GetString("elasticsearch.password"),
+	}
+
+	if caFile := v.GetString("elasticsearch.ca_file"); caFile != "" {
+		caCert, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read Elasticsearch CA certificate: %w", err)
+		}
+
+		esConfig.CACert = caCert
+	}
+
+	return esConfig, nil
+}
+
+func readTopicConfig(v *viper.Viper) *TopicConfig {
+	return &TopicConfig{
+		Prefix: v.GetString("topics.prefix"),
+		Suffix: v.GetString("topics.suffix"),
 	}
 }
 
-func readKafkaConfig() *kafka.ConfigMap {
-	kafkaConfigMap := make(kafka.ConfigMap)
+func readEncodingConfig(v *viper.Viper) *EncodingConfig {
+	encodingConfig := &EncodingConfig{
+		Default: v.GetString("encoding.default"),
+		Topics:  v.GetStringMapString("encoding.topics"),
+	}
 
-	stringKeys := []string{
-		"bootstrap.servers",
-		"security.protocol",
-		"sasl.mechanism",
-		"sasl.username",
-		"sasl.password",
+	if encodingConfig.Default == "" {
+		encodingConfig.Default = "json"
 	}
 
-	for _, key := range stringKeys {
-		kafkaConfigMap[key] = viper.GetString(fmt.Sprintf("kafka.%s", key))
+	return encodingConfig
+}
+
+func readSchemaRegistryConfig(v *viper.Viper) *schemaregistry.Config {
+	return &schemaregistry.Config{
+		URL:      v.GetString("schema_registry.url"),
+		Username: v.GetString("schema_registry.username"),
+		Password: v.GetString("schema_registry.password"),
 	}
+}
 
-	intKeys := []string{"session.timeout.ms"}
+func readProducerConfig(v *viper.Viper) *ProducerConfig {
+	v.SetDefault("producer.interval", 3*time.Second)
 
-	for _, key := range intKeys {
-		kafkaConfigMap[key] = viper.GetInt(fmt.Sprintf("kafka.%s", key))
+	return &ProducerConfig{
+		Interval:    v.GetDuration("producer.interval"),
+		Leagues:     v.GetStringSlice("producer.leagues"),
+		HTTPAddress: v.GetString("producer.http.address"),
+		Scenarios:   v.GetStringSlice("producer.scenarios"),
 	}
+}
+
+func readConsumerConfig(v *viper.Viper) *ConsumerConfig {
+	v.SetDefault("consumer.sinks", map[string][]string{"football-match-new": {"dynamodb", "elasticsearch"}})
+	v.SetDefault("consumer.failure_policy", "block")
+	v.SetDefault("consumer.max_attempts", 5)
+	v.SetDefault("consumer.commit.batch_size", 100)
+	v.SetDefault("consumer.commit.interval", 5*time.Second)
+	v.SetDefault("consumer.partition_queue_size", 100)
+	v.SetDefault("consumer.shutdown_timeout", 30*time.Second)
+	v.SetDefault("consumer.retry.max_attempts", 3)
+	v.SetDefault("consumer.retry.initial_backoff", 100*time.Millisecond)
+	v.SetDefault("consumer.retry.max_backoff", 5*time.Second)
+	v.SetDefault("consumer.circuit_breaker.failure_threshold", 5)
+	v.SetDefault("consumer.circuit_breaker.open_timeout", 30*time.Second)
+	v.SetDefault("consumer.dynamodb.table", "FootballMatches")
+	v.SetDefault("consumer.dynamodb.batch_size", 25)
+	v.SetDefault("consumer.dynamodb.flush_interval", time.Second)
+	v.SetDefault("consumer.elasticsearch.index", "football-matches")
+	v.SetDefault("consumer.elasticsearch.batch_size", 500)
+	v.SetDefault("consumer.elasticsearch.batch_bytes", 5*1024*1024)
+	v.SetDefault("consumer.elasticsearch.flush_interval", time.Second)
+	v.SetDefault("consumer.postgres.batch_size", 500)
+	v.SetDefault("consumer.postgres.flush_interval", time.Second)
+	v.SetDefault("consumer.postgres.migrate", true)
+	v.SetDefault("consumer.archive.dir", "archive")
+	v.SetDefault("consumer.archive.staging_dir", os.TempDir())
+	v.SetDefault("consumer.archive.max_file_bytes", 128*1024*1024)
+	v.SetDefault("consumer.archive.max_file_age", 5*time.Minute)
+
+	return &ConsumerConfig{
+		Sinks:           v.GetStringMapStringSlice("consumer.sinks"),
+		FailurePolicy:   v.GetString("consumer.failure_policy"),
+		MaxAttempts:     v.GetInt("consumer.max_attempts"),
+		CommitBatchSize: v.GetInt("consumer.commit.batch_size"),
+		CommitInterval:  v.GetDuration("consumer.commit.interval"),
+
+		PartitionQueueSize: v.GetInt("consumer.partition_queue_size"),
+		ShutdownTimeout:    v.GetDuration("consumer.shutdown_timeout"),
 
-	return &kafkaConfigMap
+		RetryMaxAttempts:    v.GetInt("consumer.retry.max_attempts"),
+		RetryInitialBackoff: v.GetDuration("consumer.retry.initial_backoff"),
+		RetryMaxBackoff:     v.GetDuration("consumer.retry.max_backoff"),
+
+		BreakerFailureThreshold: v.GetInt("consumer.circuit_breaker.failure_threshold"),
+		BreakerOpenTimeout:      v.GetDuration("consumer.circuit_breaker.open_timeout"),
+
+		DynamoDBTable:         v.GetString("consumer.dynamodb.table"),
+		DynamoDBEndpoint:      v.GetString("consumer.dynamodb.endpoint"),
+		DynamoDBBatchSize:     v.GetInt("consumer.dynamodb.batch_size"),
+		DynamoDBFlushInterval: v.GetDuration("consumer.dynamodb.flush_interval"),
+
+		ElasticsearchIndex:         v.GetString("consumer.elasticsearch.index"),
+		ElasticsearchBatchSize:     v.GetInt("consumer.elasticsearch.batch_size"),
+		ElasticsearchBatchBytes:    v.GetInt("consumer.elasticsearch.batch_bytes"),
+		ElasticsearchFlushInterval: v.GetDuration("consumer.elasticsearch.flush_interval"),
+
+		PostgresURL:           v.GetString("consumer.postgres.url"),
+		PostgresBatchSize:     v.GetInt("consumer.postgres.batch_size"),
+		PostgresFlushInterval: v.GetDuration("consumer.postgres.flush_interval"),
+		PostgresMigrate:       v.GetBool("consumer.postgres.migrate"),
+
+		ArchiveBucket:       v.GetString("consumer.archive.bucket"),
+		ArchiveEndpoint:     v.GetString("consumer.archive.endpoint"),
+		ArchiveDir:          v.GetString("consumer.archive.dir"),
+		ArchivePrefix:       v.GetString("consumer.archive.prefix"),
+		ArchiveStagingDir:   v.GetString("consumer.archive.staging_dir"),
+		ArchiveMaxFileBytes: v.GetInt64("consumer.archive.max_file_bytes"),
+		ArchiveMaxFileAge:   v.GetDuration("consumer.archive.max_file_age"),
+	}
 }
 
-func readAWSConfig() *aws.Config {
-	return &aws.Config{
-		Region: viper.GetString("aws.region"),
-		Credentials: aws.CredentialsProviderFunc(func(_ context.Context) (aws.Credentials, error) {
-			return aws.Credentials{
-				AccessKeyID:     viper.GetString("aws.access_key_id"),
-				SecretAccessKey: viper.GetString("aws.secret_access_key"),
-			}, nil
-		}),
+// ForTopic returns the encoding configured for the topic, falling back to the default encoding.
+func (ec *EncodingConfig) ForTopic(topic string) string {
+	if encoding, ok := ec.Topics[topic]; ok {
+		return encoding
 	}
+
+	return ec.Default
+}
+
+// Name returns the name in Kafka of the topic.
+func (tc *TopicConfig) Name(topic string) string {
+	return tc.Prefix + topic + tc.Suffix
 }
 
-func readElasticsearchConfig() *elasticsearch.Config {
-	return &elasticsearch.Config{
-		CloudID: viper.GetString("elasticsearch.cloud_id"),
-		APIKey:  viper.GetString("elasticsearch.api_key"),
+// Logical returns the topic that a Kafka topic name was made from by Name,
+// reporting whether the name has the prefix and suffix of this environment.
+func (tc *TopicConfig) Logical(name string) (string, bool) {
+	topic, ok := strings.CutPrefix(name, tc.Prefix)
+	if !ok {
+		return "", false
 	}
+
+	topic, ok = strings.CutSuffix(topic, tc.Suffix)
+	if !ok || topic == "" {
+		return "", false
+	}
+
+	return topic, true
 }
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes a config file with the given contents to a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return file
+}
+
+const layeredConfig = `
+[aws]
+access_key_id = "test"
+secret_access_key = "test"
+
+[kafka]
+bootstrap.servers = "file-broker:9092"
+
+[consumer.dynamodb]
+table = "FileMatches"
+
+[profiles.staging.kafka]
+bootstrap.servers = "profile-broker:9092"
+
+[profiles.staging.consumer.dynamodb]
+table = "ProfileMatches"
+`
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeConfigFile(t, layeredConfig)
+
+	tests := []struct {
+		name      string
+		opts      Options
+		env       map[string]string
+		wantTable string
+		wantKafka string
+	}{
+		{
+			name:      "file",
+			opts:      Options{File: file},
+			wantTable: "FileMatches",
+			wantKafka: "file-broker:9092",
+		},
+		{
+			name:      "profile over file",
+			opts:      Options{File: file, Profile: "staging"},
+			wantTable: "ProfileMatches",
+			wantKafka: "profile-broker:9092",
+		},
+		{
+			name:      "profile from the environment",
+			opts:      Options{File: file},
+			env:       map[string]string{EnvPrefix + "_PROFILE": "staging"},
+			wantTable: "ProfileMatches",
+			wantKafka: "profile-broker:9092",
+		},
+		{
+			name: "environment over profile",
+			opts: Options{File: file, Profile: "staging"},
+			env: map[string]string{
+				EnvPrefix + "_CONSUMER_DYNAMODB_TABLE": "EnvMatches",
+				EnvPrefix + "_KAFKA_BOOTSTRAP_SERVERS": "env-broker:9092",
+			},
+			wantTable: "EnvMatches",
+			wantKafka: "env-broker:9092",
+		},
+		{
+			name: "overrides over environment",
+			opts: Options{
+				File:      file,
+				Profile:   "staging",
+				Overrides: []string{"consumer.dynamodb.table=FlagMatches", "kafka.bootstrap.servers=flag-broker:9092"},
+			},
+			env: map[string]string{
+				EnvPrefix + "_CONSUMER_DYNAMODB_TABLE": "EnvMatches",
+				EnvPrefix + "_KAFKA_BOOTSTRAP_SERVERS": "env-broker:9092",
+			},
+			wantTable: "FlagMatches",
+			wantKafka: "flag-broker:9092",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			cfg, err := Load(tt.opts)
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if cfg.ConsumerConfig.DynamoDBTable != tt.wantTable {
+				t.Errorf("consumer.dynamodb.table = %q, want %q", cfg.ConsumerConfig.DynamoDBTable, tt.wantTable)
+			}
+
+			if servers := (*cfg.KafkaConfigMap)["bootstrap.servers"]; servers != tt.wantKafka {
+				t.Errorf("kafka.bootstrap.servers = %v, want %q", servers, tt.wantKafka)
+			}
+		})
+	}
+}
+
+func TestLoadDefaults(t *testing.T) {
+	cfg, err := Load(Options{File: writeConfigFile(t, "[aws]\naccess_key_id = \"test\"\n")})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.ConsumerConfig.DynamoDBTable != "FootballMatches" {
+		t.Errorf("consumer.dynamodb.table = %q, want the default FootballMatches", cfg.ConsumerConfig.DynamoDBTable)
+	}
+
+	if cfg.ConsumerConfig.ShutdownTimeout != 30*time.Second {
+		t.Errorf("consumer.shutdown_timeout = %s, want the default 30s", cfg.ConsumerConfig.ShutdownTimeout)
+	}
+
+	if cfg.ProducerConfig.Interval != 3*time.Second {
+		t.Errorf("producer.interval = %s, want the default 3s", cfg.ProducerConfig.Interval)
+	}
+
+	if cfg.EncodingConfig.Default != "json" {
+		t.Errorf("encoding.default = %q, want the default json", cfg.EncodingConfig.Default)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := writeConfigFile(t, layeredConfig)
+
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"missing file", Options{File: filepath.Join(t.TempDir(), "missing.toml")}},
+		{"unknown profile", Options{File: file, Profile: "production"}},
+		{"unknown producer profile", Options{File: file, Overrides: []string{"kafka.producer.profile=fastest"}}},
+		{"invalid log level", Options{File: file, Overrides: []string{"log.level=verbose"}}},
+	}
+
+	for _, tt := range tests {
+		if _, err := Load(tt.opts); err == nil {
+			t.Errorf("%s: Load() error = nil, want an error", tt.name)
+		}
+	}
+}
+
+func TestLoadKafkaEnv(t *testing.T) {
+	// a Kafka setting that only the environment sets is still passed through to librdkafka
+	t.Setenv(EnvPrefix+"_KAFKA_SASL_PASSWORD", "env-password")
+
+	cfg, err := Load(Options{File: writeConfigFile(t, layeredConfig)})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if password := (*cfg.KafkaConfigMap)["sasl.password"]; password != "env-password" {
+		t.Errorf("kafka.sasl.password = %v, want env-password", password)
+	}
+}
+
+func TestRegisterFlags(t *testing.T) {
+	var opts Options
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	opts.RegisterFlags(fs)
+
+	if err := fs.Parse([]string{"-config", "other.toml", "-profile", "staging", "-set", "a.b=1", "-set", "c=x=y"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if opts.File != "other.toml" || opts.Profile != "staging" || !slices.Equal(opts.Overrides, []string{"a.b=1", "c=x=y"}) {
+		t.Errorf("options = %+v, want the file, the profile and both overrides", opts)
+	}
+
+	if err := fs.Parse([]string{"-set", "novalue"}); err == nil {
+		t.Error("Parse() error = nil, want an error for an override without a value")
+	}
+}
+
+func TestTopicConfig(t *testing.T) {
+	tc := &TopicConfig{Prefix: "staging.", Suffix: ".v1"}
+
+	if name := tc.Name("football-match-new"); name != "staging.football-match-new.v1" {
+		t.Errorf("Name() = %q, want staging.football-match-new.v1", name)
+	}
+
+	tests := []struct {
+		name   string
+		topic  string
+		wantOK bool
+	}{
+		{"staging.football-match-new.v1", "football-match-new", true},
+		{"football-match-new.v1", "", false},
+		{"staging.football-match-new", "", false},
+		{"staging..v1", "", false},
+	}
+
+	for _, tt := range tests {
+		if topic, ok := tc.Logical(tt.name); topic != tt.topic || ok != tt.wantOK {
+			t.Errorf("Logical(%q) = %q, %t, want %q, %t", tt.name, topic, ok, tt.topic, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/spf13/viper"
+)
+
+// ProducerProfiles are named sets of librdkafka producer settings tuned for a kind of workload.
+// Settings under [kafka.producer] in the config file take precedence over the selected profile.
+var ProducerProfiles = map[string]kafka.ConfigMap{
+	// send every message as soon as possible, trading throughput and durability for latency
+	"low-latency": {
+		"acks":                 "1",
+		"linger.ms":            0,
+		"batch.num.messages":   1,
+		"compression.type":     "none",
+		"socket.nagle.disable": true,
+	},
+	// build large compressed batches to move as many messages as possible per request
+	"high-throughput": {
+		"acks":                         "1",
+		"linger.ms":                    100,
+		"batch.size":                   1048576,
+		"batch.num.messages":           100000,
+		"compression.type":             "lz4",
+		"queue.buffering.max.messages": 1000000,
+		"queue.buffering.max.kbytes":   1048576,
+	},
+	// never lose or duplicate an acknowledged message, even across broker failures and retries
+	"durable": {
+		"acks":                                  "all",
+		"enable.idempotence":                    true,
+		"max.in.flight.requests.per.connection": 5,
+		"message.send.max.retries":              2147483647,
+		"linger.ms":                             5,
+		"compression.type":                      "zstd",
+	},
+}
+
+// client-specific sections that are not passed through to the shared Kafka config
+const (
+	kafkaProducerSection = "kafka.producer."
+	kafkaConsumerSection = "kafka.consumer."
+)
+
+// readKafkaConfig passes every key under [kafka], except the producer and consumer sections, through to librdkafka.
+func readKafkaConfig(v *viper.Viper) *kafka.ConfigMap {
+	kafkaConfigMap := make(kafka.ConfigMap)
+
+	for _, key := range v.AllKeys() {
+		if !strings.HasPrefix(key, "kafka.") ||
+			strings.HasPrefix(key, kafkaProducerSection) ||
+			strings.HasPrefix(key, kafkaConsumerSection) {
+			continue
+		}
+
+		kafkaConfigMap[strings.TrimPrefix(key, "kafka.")] = kafkaConfigValue(v.Get(key))
+	}
+
+	return &kafkaConfigMap
+}
+
+// readKafkaProducerConfig layers the selected producer profile and the [kafka.producer] settings on top of the shared config.
+func readKafkaProducerConfig(v *viper.Viper, kafkaConfigMap *kafka.ConfigMap) (*kafka.ConfigMap, error) {
+	producerConfigMap, err := WithProducerProfile(kafkaConfigMap, v.GetString(kafkaProducerSection+"profile"))
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range readKafkaSection(v, kafkaProducerSection) {
+		if key == "profile" {
+			continue
+		}
+
+		(*producerConfigMap)[key] = value
+	}
+
+	return producerConfigMap, nil
+}
+
+// readKafkaConsumerConfig layers the [kafka.consumer] settings on top of the shared config.
+// Offsets are always committed by the consumer itself, once every sink has confirmed the write.
+func readKafkaConsumerConfig(v *viper.Viper, kafkaConfigMap *kafka.ConfigMap) *kafka.ConfigMap {
+	consumerConfigMap := cloneKafkaConfig(kafkaConfigMap)
+
+	(*consumerConfigMap)["group.id"] = "go-group-1"
+	(*consumerConfigMap)["auto.offset.reset"] = "earliest"
+
+	for key, value := range readKafkaSection(v, kafkaConsumerSection) {
+		(*consumerConfigMap)[key] = value
+	}
+
+	(*consumerConfigMap)["enable.auto.commit"] = false
+	(*consumerConfigMap)["enable.auto.offset.store"] = false
+
+	return consumerConfigMap
+}
+
+// WithProducerProfile returns a copy of the Kafka config with the settings of the named producer profile applied.
+// An empty profile name returns an unmodified copy.
+func WithProducerProfile(kafkaConfigMap *kafka.ConfigMap, profile string) (*kafka.ConfigMap, error) {
+	producerConfigMap := cloneKafkaConfig(kafkaConfigMap)
+
+	if profile == "" {
+		return producerConfigMap, nil
+	}
+
+	settings, ok := ProducerProfiles[profile]
+	if !ok {
+		return nil, fmt.Errorf("unknown Kafka producer profile %q", profile)
+	}
+
+	for key, value := range settings {
+		(*producerConfigMap)[key] = value
+	}
+
+	return producerConfigMap, nil
+}
+
+func readKafkaSection(v *viper.Viper, section string) kafka.ConfigMap {
+	settings := make(kafka.ConfigMap)
+
+	for _, key := range v.AllKeys() {
+		if strings.HasPrefix(key, section) {
+			settings[strings.TrimPrefix(key, section)] = kafkaConfigValue(v.Get(key))
+		}
+	}
+
+	return settings
+}
+
+func cloneKafkaConfig(kafkaConfigMap *kafka.ConfigMap) *kafka.ConfigMap {
+	clone := make(kafka.ConfigMap, len(*kafkaConfigMap))
+
+	for key, value := range *kafkaConfigMap {
+		clone[key] = value
+	}
+
+	return &clone
+}
+
+// kafkaConfigValue converts values decoded from the config file into types accepted by kafka.ConfigMap.
+func kafkaConfigValue(value any) kafka.ConfigValue {
+	switch v := value.(type) {
+	case int64:
+		return int(v)
+	case []any:
+		values := make([]string, len(v))
+		for i, item := range v {
+			values[i] = fmt.Sprint(item)
+		}
+
+		return strings.Join(values, ",")
+	default:
+		return v
+	}
+}
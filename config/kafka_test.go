@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func TestWithProducerProfile(t *testing.T) {
+	shared := &kafka.ConfigMap{"bootstrap.servers": "localhost:9092", "acks": "0"}
+
+	got, err := WithProducerProfile(shared, "durable")
+	if err != nil {
+		t.Fatalf("WithProducerProfile() error = %v", err)
+	}
+
+	if (*got)["acks"] != "all" || (*got)["enable.idempotence"] != true || (*got)["bootstrap.servers"] != "localhost:9092" {
+		t.Errorf("WithProducerProfile() = %v, want the durable settings over the shared config", *got)
+	}
+
+	if (*shared)["acks"] != "0" {
+		t.Errorf("WithProducerProfile() modified the shared config: %v", *shared)
+	}
+
+	unchanged, err := WithProducerProfile(shared, "")
+	if err != nil || len(*unchanged) != len(*shared) {
+		t.Errorf("WithProducerProfile() with no profile = %v, %v, want a copy of %v", unchanged, err, *shared)
+	}
+
+	if _, err := WithProducerProfile(shared, "fastest"); err == nil {
+		t.Error("WithProducerProfile() of an unknown profile error = nil, want an error")
+	}
+}
+
+func TestKafkaConfigValue(t *testing.T) {
+	tests := []struct {
+		value any
+		want  kafka.ConfigValue
+	}{
+		{int64(100), 100},
+		{[]any{"PLAIN", "SCRAM-SHA-512"}, "PLAIN,SCRAM-SHA-512"},
+		{"lz4", "lz4"},
+		{true, true},
+	}
+
+	for _, tt := range tests {
+		if got := kafkaConfigValue(tt.value); got != tt.want {
+			t.Errorf("kafkaConfigValue(%#v) = %#v, want %#v", tt.value, got, tt.want)
+		}
+	}
+}
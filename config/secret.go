@@ -0,0 +1,74 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Prefixes of secret references, which Load replaces with the secret they point at.
+const (
+	// SecretFilePrefix reads the secret from a file, such as a mounted Kubernetes secret: "file:/etc/secrets/kafka-password".
+	SecretFilePrefix = "file:"
+	// SecretEnvPrefix reads the secret from an environment variable: "env:KAFKA_PASSWORD".
+	SecretEnvPrefix = "env:"
+)
+
+// ResolveSecret returns the secret that value refers to, or value itself when it is not a secret reference.
+// Trailing newlines are trimmed from secrets read from files.
+func ResolveSecret(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, SecretFilePrefix):
+		path := strings.TrimPrefix(value, SecretFilePrefix)
+
+		secret, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("Failed to read secret file: %w", err)
+		}
+
+		return strings.TrimRight(string(secret), "\r\n"), nil
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimPrefix(value, SecretEnvPrefix)
+
+		secret, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("secret environment variable %s is not set", name)
+		}
+
+		return secret, nil
+	default:
+		return value, nil
+	}
+}
+
+// resolveSecrets replaces every setting that is a secret reference with its secret,
+// so that any key, such as kafka.sasl.password or elasticsearch.api_key, can be kept out of the config file.
+// The secrets of profiles are only resolved once applied, as those of other environments may not be available.
+func resolveSecrets(v *viper.Viper) error {
+	var errs []error
+
+	for _, key := range v.AllKeys() {
+		if strings.HasPrefix(key, profilesSection) {
+			continue
+		}
+
+		value, ok := v.Get(key).(string)
+		if !ok || (!strings.HasPrefix(value, SecretFilePrefix) && !strings.HasPrefix(value, SecretEnvPrefix)) {
+			continue
+		}
+
+		secret, err := ResolveSecret(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+
+			continue
+		}
+
+		v.Set(key, secret)
+	}
+
+	return errors.Join(errs...)
+}
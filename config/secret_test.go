@@ -0,0 +1,114 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "kafka-password")
+	if err := os.WriteFile(file, []byte("file-secret\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("TEST_KAFKA_PASSWORD", "env-secret")
+
+	tests := []struct {
+		name    string
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{"plain value", "plain-secret", "plain-secret", false},
+		{"file", SecretFilePrefix + file, "file-secret", false},
+		{"missing file", SecretFilePrefix + filepath.Join(t.TempDir(), "missing"), "", true},
+		{"environment variable", SecretEnvPrefix + "TEST_KAFKA_PASSWORD", "env-secret", false},
+		{"unset environment variable", SecretEnvPrefix + "TEST_UNSET_PASSWORD", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ResolveSecret(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: ResolveSecret() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+
+		if got != tt.want {
+			t.Errorf("%s: ResolveSecret() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoadResolvesSecrets(t *testing.T) {
+	t.Setenv("TEST_KAFKA_PASSWORD", "kafka-secret")
+	t.Setenv("TEST_ES_API_KEY", "es-secret")
+
+	file := writeConfigFile(t, `
+[aws]
+access_key_id = "test"
+
+[kafka.sasl]
+password = "env:TEST_KAFKA_PASSWORD"
+
+[profiles.production.elasticsearch]
+api_key = "env:TEST_PRODUCTION_API_KEY"
+`)
+
+	// a secret reference can also come from the environment or an override,
+	// and the unresolvable secrets of a profile that is not applied are left alone
+	cfg, err := Load(Options{File: file, Overrides: []string{"elasticsearch.api_key=env:TEST_ES_API_KEY"}})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if password := (*cfg.KafkaConfigMap)["sasl.password"]; password != "kafka-secret" {
+		t.Errorf("kafka.sasl.password = %v, want kafka-secret", password)
+	}
+
+	if cfg.ElasticsearchConfig.APIKey != "es-secret" {
+		t.Errorf("elasticsearch.api_key = %q, want es-secret", cfg.ElasticsearchConfig.APIKey)
+	}
+
+	// applying the profile resolves its secrets, which fails while they are not available
+	if _, err := Load(Options{File: file, Profile: "production"}); err == nil {
+		t.Error("Load() with an unresolvable secret error = nil, want an error")
+	}
+}
+
+func TestLoadAWSCredentials(t *testing.T) {
+	// keep the shared config and credentials files of the machine running the tests out of the chain
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_ACCESS_KEY_ID", "chain-key")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "chain-secret")
+
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{"static keys", "[aws]\nregion = \"eu-west-1\"\naccess_key_id = \"static-key\"\nsecret_access_key = \"static-secret\"\n", "static-key"},
+		{"default credential chain", "[aws]\nregion = \"eu-west-1\"\n", "chain-key"},
+	}
+
+	for _, tt := range tests {
+		cfg, err := Load(Options{File: writeConfigFile(t, tt.config)})
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", tt.name, err)
+		}
+
+		credentials, err := cfg.AWSConfig.Credentials.Retrieve(context.Background())
+		if err != nil {
+			t.Fatalf("%s: Retrieve() error = %v", tt.name, err)
+		}
+
+		if credentials.AccessKeyID != tt.want {
+			t.Errorf("%s: access key ID = %q, want %q", tt.name, credentials.AccessKeyID, tt.want)
+		}
+
+		if cfg.AWSConfig.Region != "eu-west-1" {
+			t.Errorf("%s: region = %q, want eu-west-1", tt.name, cfg.AWSConfig.Region)
+		}
+	}
+}
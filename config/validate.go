@@ -0,0 +1,355 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/elastic/go-elasticsearch/v8"
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/codec"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// placeholderPrefix marks the values of config.example.toml that must be replaced with your own.
+const placeholderPrefix = "BYO "
+
+// Problem is a setting that is missing, malformed or conflicts with another one.
+type Problem struct {
+	Key     string
+	Message string
+}
+
+func (p Problem) String() string {
+	return p.Key + ": " + p.Message
+}
+
+// ValidationError lists every problem found in a config.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (ve *ValidationError) Error() string {
+	lines := make([]string, len(ve.Problems))
+	for i, problem := range ve.Problems {
+		lines[i] = problem.String()
+	}
+
+	return fmt.Sprintf("%d config problems:\n  %s", len(ve.Problems), strings.Join(lines, "\n  "))
+}
+
+// ValidateOptions tunes Validate.
+type ValidateOptions struct {
+	// AllowPlaceholders accepts the "BYO ..." values of config.example.toml, for checking the example itself.
+	AllowPlaceholders bool
+	// Topics maps every topic that the consumer consumes to the names of the sinks that can store its records.
+	// Any topic and sink is accepted when empty.
+	Topics map[string][]string
+}
+
+// validator collects the problems of a config, so that all of them are reported at once.
+type validator struct {
+	opts     ValidateOptions
+	problems []Problem
+}
+
+func (v *validator) addf(key, format string, args ...any) {
+	problem := Problem{Key: key, Message: fmt.Sprintf(format, args...)}
+
+	// settings needed by several features, such as aws.region, are only reported once
+	if !slices.Contains(v.problems, problem) {
+		v.problems = append(v.problems, problem)
+	}
+}
+
+// required reports a value that is empty or, unless placeholders are allowed, still the example's placeholder.
+func (v *validator) required(key, value string) bool {
+	switch {
+	case strings.TrimSpace(value) == "":
+		v.addf(key, "is required")
+	case strings.HasPrefix(value, placeholderPrefix) && !v.opts.AllowPlaceholders:
+		v.addf(key, "is still the placeholder %q, replace it with your own value", value)
+	default:
+		return true
+	}
+
+	return false
+}
+
+func (v *validator) positive(key string, value int64) {
+	if value <= 0 {
+		v.addf(key, "must be greater than 0, got %d", value)
+	}
+}
+
+// Validate checks the whole config and returns a *ValidationError listing every problem found, or nil.
+// Settings of sinks that no topic is written to are not checked.
+func (c *Config) Validate(opts ValidateOptions) error {
+	v := &validator{opts: opts}
+
+	v.kafka(c.KafkaConfigMap, c.KafkaConsumerConfigMap)
+	v.topics(c.Topics)
+	v.encoding(c.EncodingConfig, c.SchemaRegistryConfig.URL)
+	v.producer(c.ProducerConfig)
+	v.consumer(c)
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Problems: v.problems}
+}
+
+func (v *validator) kafka(kafkaConfigMap, consumerConfigMap *kafka.ConfigMap) {
+	setting := func(configMap *kafka.ConfigMap, name string) string {
+		if value, ok := (*configMap)[name]; ok {
+			return fmt.Sprint(value)
+		}
+
+		return ""
+	}
+
+	v.required("kafka.bootstrap.servers", setting(kafkaConfigMap, "bootstrap.servers"))
+
+	protocol := strings.ToUpper(setting(kafkaConfigMap, "security.protocol"))
+	if protocol != "" && !slices.Contains([]string{"PLAINTEXT", "SSL", "SASL_PLAINTEXT", "SASL_SSL"}, protocol) {
+		v.addf("kafka.security.protocol", "must be one of PLAINTEXT, SSL, SASL_PLAINTEXT or SASL_SSL, got %q", protocol)
+	}
+
+	// librdkafka accepts both spellings of the mechanism
+	mechanism := setting(kafkaConfigMap, "sasl.mechanism")
+	if mechanism == "" {
+		mechanism = setting(kafkaConfigMap, "sasl.mechanisms")
+	}
+
+	if strings.HasPrefix(protocol, "SASL_") {
+		if v.required("kafka.sasl.mechanism", mechanism) {
+			switch strings.ToUpper(mechanism) {
+			case "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512":
+				v.required("kafka.sasl.username", setting(kafkaConfigMap, "sasl.username"))
+				v.required("kafka.sasl.password", setting(kafkaConfigMap, "sasl.password"))
+			case "GSSAPI", "OAUTHBEARER":
+			default:
+				v.addf("kafka.sasl.mechanism", "must be one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512, GSSAPI or OAUTHBEARER, got %q", mechanism)
+			}
+		}
+	} else if mechanism != "" || setting(kafkaConfigMap, "sasl.username") != "" {
+		v.addf("kafka.sasl", "is set but kafka.security.protocol is %q, so it is ignored; use SASL_SSL or SASL_PLAINTEXT", protocol)
+	}
+
+	v.required("kafka.consumer.group.id", setting(consumerConfigMap, "group.id"))
+}
+
+// topics reports a prefix or suffix that would make an invalid Kafka topic name.
+func (v *validator) topics(topicConfig *TopicConfig) {
+	check := func(key, value string) {
+		if strings.IndexFunc(value, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-')
+		}) >= 0 {
+			v.addf(key, "may only contain letters, digits, '.', '_' and '-', got %q", value)
+		}
+	}
+
+	check("topics.prefix", topicConfig.Prefix)
+	check("topics.suffix", topicConfig.Suffix)
+}
+
+func (v *validator) encoding(encodingConfig *EncodingConfig, schemaRegistryURL string) {
+	encodings := []string{codec.JSON, codec.Protobuf, codec.Avro}
+
+	check := func(key, encoding string) {
+		if !slices.Contains(encodings, encoding) {
+			v.addf(key, "must be one of %s, got %q", strings.Join(encodings, ", "), encoding)
+		}
+	}
+
+	check("encoding.default", encodingConfig.Default)
+
+	usesAvro := encodingConfig.Default == codec.Avro
+
+	topics := maps.Keys(encodingConfig.Topics)
+	slices.Sort(topics)
+
+	for _, topic := range topics {
+		check("encoding.topics."+topic, encodingConfig.Topics[topic])
+
+		usesAvro = usesAvro || encodingConfig.Topics[topic] == codec.Avro
+	}
+
+	if usesAvro {
+		v.required("schema_registry.url", schemaRegistryURL)
+	}
+}
+
+// topicSinks checks that the consumer consumes the topic and that its sinks can store the records of the topic.
+func (v *validator) topicSinks(topic string, sinks []string) {
+	if len(v.opts.Topics) == 0 {
+		return
+	}
+
+	key := "consumer.sinks." + topic
+
+	consumed := maps.Keys(v.opts.Topics)
+	slices.Sort(consumed)
+
+	supported, ok := v.opts.Topics[topic]
+	if !ok {
+		v.addf(key, "the consumer does not consume this topic, expected one of %s", strings.Join(consumed, ", "))
+
+		return
+	}
+
+	for _, name := range sinks {
+		if !slices.Contains(supported, name) {
+			v.addf(key, "sink %q cannot store the records of this topic, expected one of %s", name, strings.Join(supported, ", "))
+		}
+	}
+}
+
+func (v *validator) producer(producerConfig *ProducerConfig) {
+	v.positive("producer.interval", int64(producerConfig.Interval))
+
+	leagues := sports.FootballLeagues()
+
+	for _, league := range producerConfig.Leagues {
+		if !slices.Contains(leagues, league) {
+			v.addf("producer.leagues", "unknown league %q, expected any of %s", league, strings.Join(leagues, ", "))
+		}
+	}
+
+	if producerConfig.HTTPAddress != "" {
+		if _, _, err := net.SplitHostPort(producerConfig.HTTPAddress); err != nil {
+			v.addf("producer.http.address", "must be a host:port listen address such as \":8080\": %s", err)
+		}
+	}
+
+	for _, path := range producerConfig.Scenarios {
+		if _, err := os.Stat(path); err != nil {
+			v.addf("producer.scenarios", "cannot read scenario file: %s", err)
+		}
+	}
+}
+
+func (v *validator) consumer(c *Config) {
+	cc := c.ConsumerConfig
+
+	switch cc.FailurePolicy {
+	case "block", "skip":
+	case "dead-letter":
+		v.positive("consumer.max_attempts", int64(cc.MaxAttempts))
+	default:
+		v.addf("consumer.failure_policy", "must be one of block, dead-letter or skip, got %q", cc.FailurePolicy)
+	}
+
+	v.positive("consumer.commit.batch_size", int64(cc.CommitBatchSize))
+	v.positive("consumer.commit.interval", int64(cc.CommitInterval))
+	v.positive("consumer.partition_queue_size", int64(cc.PartitionQueueSize))
+	v.positive("consumer.shutdown_timeout", int64(cc.ShutdownTimeout))
+	v.positive("consumer.retry.max_attempts", int64(cc.RetryMaxAttempts))
+	v.positive("consumer.retry.initial_backoff", int64(cc.RetryInitialBackoff))
+
+	if cc.RetryMaxBackoff < cc.RetryInitialBackoff {
+		v.addf("consumer.retry.max_backoff", "must not be less than consumer.retry.initial_backoff (%s), got %s",
+			cc.RetryInitialBackoff, cc.RetryMaxBackoff)
+	}
+
+	v.positive("consumer.circuit_breaker.failure_threshold", int64(cc.BreakerFailureThreshold))
+	v.positive("consumer.circuit_breaker.open_timeout", int64(cc.BreakerOpenTimeout))
+
+	if len(cc.Sinks) == 0 {
+		v.addf("consumer.sinks", "no topic is written to any sink")
+	}
+
+	enabled := make(map[string]bool)
+
+	topics := maps.Keys(cc.Sinks)
+	slices.Sort(topics)
+
+	for _, topic := range topics {
+		v.topicSinks(topic, cc.Sinks[topic])
+
+		for _, name := range cc.Sinks[topic] {
+			enabled[name] = true
+		}
+	}
+
+	if enabled["dynamodb"] {
+		v.required("aws.region", c.AWSConfig.Region)
+		v.required("consumer.dynamodb.table", cc.DynamoDBTable)
+
+		if cc.DynamoDBEndpoint != "" {
+			v.url("consumer.dynamodb.endpoint", cc.DynamoDBEndpoint)
+		}
+
+		if cc.DynamoDBBatchSize < 1 || cc.DynamoDBBatchSize > 100 {
+			v.addf("consumer.dynamodb.batch_size", "must be between 1 and 100, got %d", cc.DynamoDBBatchSize)
+		}
+
+		v.positive("consumer.dynamodb.flush_interval", int64(cc.DynamoDBFlushInterval))
+	}
+
+	if enabled["elasticsearch"] {
+		v.elasticsearch(c.ElasticsearchConfig)
+		v.required("consumer.elasticsearch.index", cc.ElasticsearchIndex)
+		v.positive("consumer.elasticsearch.batch_size", int64(cc.ElasticsearchBatchSize))
+		v.positive("consumer.elasticsearch.batch_bytes", int64(cc.ElasticsearchBatchBytes))
+		v.positive("consumer.elasticsearch.flush_interval", int64(cc.ElasticsearchFlushInterval))
+	}
+
+	if enabled["postgres"] {
+		if v.required("consumer.postgres.url", cc.PostgresURL) &&
+			!strings.HasPrefix(cc.PostgresURL, "postgres://") && !strings.HasPrefix(cc.PostgresURL, "postgresql://") {
+			v.addf("consumer.postgres.url", "must be a postgres:// connection URL")
+		}
+
+		v.positive("consumer.postgres.batch_size", int64(cc.PostgresBatchSize))
+		v.positive("consumer.postgres.flush_interval", int64(cc.PostgresFlushInterval))
+	}
+
+	if enabled["archive"] {
+		if cc.ArchiveBucket != "" {
+			v.required("aws.region", c.AWSConfig.Region)
+		} else {
+			v.required("consumer.archive.dir", cc.ArchiveDir)
+		}
+
+		v.required("consumer.archive.staging_dir", cc.ArchiveStagingDir)
+		v.positive("consumer.archive.max_file_bytes", cc.ArchiveMaxFileBytes)
+		v.positive("consumer.archive.max_file_age", int64(cc.ArchiveMaxFileAge))
+	}
+}
+
+func (v *validator) elasticsearch(esConfig *elasticsearch.Config) {
+	switch {
+	case esConfig.CloudID != "" && len(esConfig.Addresses) > 0:
+		v.addf("elasticsearch.addresses", "cannot be set together with elasticsearch.cloud_id, set only one of them")
+	case esConfig.CloudID != "":
+		v.required("elasticsearch.cloud_id", esConfig.CloudID)
+		v.required("elasticsearch.api_key", esConfig.APIKey)
+	case len(esConfig.Addresses) > 0:
+		for _, address := range esConfig.Addresses {
+			v.url("elasticsearch.addresses", address)
+		}
+	default:
+		v.addf("elasticsearch", "set either cloud_id for Elastic Cloud or addresses for a self-managed cluster")
+	}
+
+	if esConfig.APIKey != "" && (esConfig.Username != "" || esConfig.Password != "") {
+		v.addf("elasticsearch.username", "cannot be used together with elasticsearch.api_key, set only one of them")
+	} else if (esConfig.Username == "") != (esConfig.Password == "") {
+		v.addf("elasticsearch.password", "basic auth needs both elasticsearch.username and elasticsearch.password")
+	}
+}
+
+// url reports a value that is not an absolute http or https URL.
+func (v *validator) url(key, value string) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.addf(key, "must be an http:// or https:// URL, got %q", value)
+	}
+}
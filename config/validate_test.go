@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// testTopics stands in for service.TopicSinks, which this package cannot import.
+var testTopics = map[string][]string{
+	sports.TopicNewFootballMatch:   {"dynamodb", "elasticsearch", "postgres", "archive"},
+	sports.TopicFootballMatchEvent: {"archive"},
+}
+
+// validationProblems returns the keys of the problems that Validate found, failing the test on any other error.
+func validationProblems(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	var ve *ValidationError
+	if !errors.As(err, &ve) {
+		t.Fatalf("Validate() error = %v, want a *ValidationError", err)
+	}
+
+	keys := make([]string, len(ve.Problems))
+	for i, problem := range ve.Problems {
+		keys[i] = problem.Key
+	}
+
+	return keys
+}
+
+func TestValidateShippedConfigs(t *testing.T) {
+	tests := []struct {
+		file string
+		opts ValidateOptions
+	}{
+		{"../deploy/config.example.toml", ValidateOptions{AllowPlaceholders: true, Topics: testTopics}},
+		{"../deploy/config.local.toml", ValidateOptions{Topics: testTopics}},
+	}
+
+	for _, tt := range tests {
+		cfg, err := Load(Options{File: tt.file})
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", tt.file, err)
+		}
+
+		if err := cfg.Validate(tt.opts); err != nil {
+			t.Errorf("%s: Validate() error = %v", tt.file, err)
+		}
+	}
+}
+
+func TestValidatePlaceholders(t *testing.T) {
+	cfg, err := Load(Options{File: "../deploy/config.example.toml"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	got := validationProblems(t, cfg.Validate(ValidateOptions{Topics: testTopics}))
+
+	for _, key := range []string{"kafka.bootstrap.servers", "kafka.sasl.username", "kafka.sasl.password"} {
+		if !slices.Contains(got, key) {
+			t.Errorf("Validate() reported %v, want a placeholder problem for %s", got, key)
+		}
+	}
+}
+
+func TestValidateReportsEveryProblem(t *testing.T) {
+	file := writeConfigFile(t, `
+[aws]
+region = "eu-west-1"
+access_key_id = "test"
+
+[kafka]
+bootstrap.servers = "localhost:9092"
+security.protocol = "SASL_TLS"
+
+[kafka.consumer]
+group.id = "test"
+
+[topics]
+prefix = "staging/"
+
+[encoding]
+default = "xml"
+
+[producer]
+interval = "0s"
+leagues = ["Sunday League"]
+
+[consumer]
+failure_policy = "retry"
+
+[consumer.sinks]
+football-match-new = ["dynamodb", "postgres"]
+football-match-event = ["elasticsearch"]
+football-match-deleted = ["archive"]
+
+[consumer.dynamodb]
+batch_size = 101
+
+[consumer.postgres]
+url = "mysql://localhost"
+`)
+
+	cfg, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// every problem is reported at once, in the order the sections are checked
+	want := []string{
+		"kafka.security.protocol",
+		"kafka.sasl.mechanism",
+		"topics.prefix",
+		"encoding.default",
+		"producer.interval",
+		"producer.leagues",
+		"consumer.failure_policy",
+		"consumer.sinks.football-match-deleted",
+		"consumer.sinks.football-match-event",
+		"consumer.dynamodb.batch_size",
+		"elasticsearch",
+		"consumer.postgres.url",
+	}
+
+	if got := validationProblems(t, cfg.Validate(ValidateOptions{Topics: testTopics})); !slices.Equal(got, want) {
+		t.Errorf("Validate() reported %v, want %v", got, want)
+	}
+}
+
+func TestValidateUnusedSinks(t *testing.T) {
+	file := writeConfigFile(t, `
+[kafka]
+bootstrap.servers = "localhost:9092"
+
+[kafka.consumer]
+group.id = "test"
+
+[consumer.sinks]
+football-match-new = ["archive"]
+
+[consumer.postgres]
+batch_size = 0
+`)
+
+	cfg, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// the settings of sinks that no topic is written to are not checked
+	if err := cfg.Validate(ValidateOptions{Topics: testTopics}); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestValidateLocalEndpoints(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides []string
+		want      string
+	}{
+		{"cloud ID and addresses", []string{"elasticsearch.cloud_id=cluster:abc"}, "elasticsearch.addresses"},
+		{"address without scheme", []string{"elasticsearch.addresses=localhost:9200"}, "elasticsearch.addresses"},
+		{"API key and basic auth", []string{"elasticsearch.api_key=key", "elasticsearch.username=elastic"}, "elasticsearch.username"},
+		{"username without password", []string{"elasticsearch.username=elastic"}, "elasticsearch.password"},
+		{"DynamoDB endpoint without scheme", []string{"consumer.dynamodb.endpoint=localhost:8000"}, "consumer.dynamodb.endpoint"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := Load(Options{File: "../deploy/config.local.toml", Overrides: tt.overrides})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			if got := validationProblems(t, cfg.Validate(ValidateOptions{Topics: testTopics})); !slices.Equal(got, []string{tt.want}) {
+				t.Errorf("Validate() reported %v, want %s", got, tt.want)
+			}
+		})
+	}
+}
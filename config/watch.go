@@ -0,0 +1,229 @@
+package config
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"golang.org/x/exp/maps"
+)
+
+// reloadDelay lets an editor finish writing the config file before it is reloaded,
+// as saving a file often fires several events.
+const reloadDelay = 200 * time.Millisecond
+
+// liveSettings are the fields of ProducerConfig and ConsumerConfig that the running services apply on reload.
+// A change to any other setting only takes effect after a restart.
+var liveSettings = []string{
+	"ProducerConfig.Interval",
+	"ProducerConfig.Leagues",
+	"ConsumerConfig.CommitBatchSize",
+	"ConsumerConfig.CommitInterval",
+	"ConsumerConfig.DynamoDBBatchSize",
+	"ConsumerConfig.DynamoDBFlushInterval",
+	"ConsumerConfig.ElasticsearchBatchSize",
+	"ConsumerConfig.ElasticsearchBatchBytes",
+	"ConsumerConfig.ElasticsearchFlushInterval",
+	"ConsumerConfig.PostgresBatchSize",
+	"ConsumerConfig.PostgresFlushInterval",
+	"ConsumerConfig.ArchiveMaxFileBytes",
+	"ConsumerConfig.ArchiveMaxFileAge",
+}
+
+// Change is a config reloaded after its file changed.
+type Change struct {
+	Old *Config
+	New *Config
+	// Rejected names the settings that changed but cannot be applied while running, such as kafka.bootstrap.servers.
+	// Subscribers only apply the live settings of New, so these keep their old value until a restart.
+	Rejected []string
+}
+
+// Watcher reloads the config whenever its file changes and notifies its subscribers of valid changes.
+type Watcher struct {
+	Options  Options
+	Validate ValidateOptions
+	Log      *slog.Logger
+
+	mu          sync.Mutex
+	current     *Config
+	subscribers []func(Change)
+}
+
+// NewWatcher creates a watcher of the config file that current was loaded from with opts.
+func NewWatcher(opts Options, current *Config, validate ValidateOptions, logger *slog.Logger) *Watcher {
+	return &Watcher{
+		Options:  opts,
+		Validate: validate,
+		Log:      logger,
+		current:  current,
+	}
+}
+
+// Subscribe registers a function that is called with every change, on the goroutine of Run.
+func (w *Watcher) Subscribe(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Run watches the config file until the context is done. It does nothing when there is no config file.
+func (w *Watcher) Run(ctx context.Context) error {
+	file, ok := w.Options.configFile()
+	if !ok {
+		<-ctx.Done()
+
+		return nil
+	}
+
+	changed := make(chan struct{}, 1)
+
+	v := viper.New()
+	v.SetConfigFile(file)
+	v.SetConfigType("toml")
+	v.OnConfigChange(func(fsnotify.Event) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	v.WatchConfig()
+
+	w.Log.Info("Watching " + file + " for changes")
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-changed:
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reloadDelay):
+		}
+
+		// events that arrived while waiting are covered by this reload
+		select {
+		case <-changed:
+		default:
+		}
+
+		w.reload()
+	}
+}
+
+// reload loads and validates the config again, keeping the current one when the new one is invalid.
+func (w *Watcher) reload() {
+	cfg, err := Load(w.Options)
+	if err == nil {
+		err = cfg.Validate(w.Validate)
+	}
+
+	if err != nil {
+		w.Log.Error("Ignoring the changed config: " + err.Error())
+
+		return
+	}
+
+	w.mu.Lock()
+	change := Change{Old: w.current, New: cfg, Rejected: rejectedSettings(w.current, cfg)}
+	w.current = cfg
+	subscribers := slices.Clone(w.subscribers)
+	w.mu.Unlock()
+
+	if len(change.Rejected) > 0 {
+		w.Log.Warn("These config changes only take effect after a restart: " + strings.Join(change.Rejected, ", "))
+	}
+
+	w.Log.Info("Reloaded the config")
+
+	for _, fn := range subscribers {
+		fn(change)
+	}
+}
+
+// rejectedSettings lists the settings that differ between before and after but are not live.
+func rejectedSettings(before, after *Config) []string {
+	var rejected []string
+
+	kafkaSettings := func(section string, oldMap, newMap *kafka.ConfigMap) {
+		keys := append(maps.Keys(*oldMap), maps.Keys(*newMap)...)
+		slices.Sort(keys)
+
+		for _, key := range slices.Compact(keys) {
+			if !reflect.DeepEqual((*oldMap)[key], (*newMap)[key]) {
+				rejected = append(rejected, section+key)
+			}
+		}
+	}
+
+	kafkaSettings("kafka.", before.KafkaConfigMap, after.KafkaConfigMap)
+	kafkaSettings("kafka.producer.", before.KafkaProducerConfigMap, after.KafkaProducerConfigMap)
+	kafkaSettings("kafka.consumer.", before.KafkaConsumerConfigMap, after.KafkaConsumerConfigMap)
+
+	// settings shared with the clients show up in their maps too
+	rejected = slices.DeleteFunc(rejected, func(key string) bool {
+		for _, section := range []string{"kafka.producer.", "kafka.consumer."} {
+			if name, ok := strings.CutPrefix(key, section); ok && slices.Contains(rejected, "kafka."+name) {
+				return true
+			}
+		}
+
+		return false
+	})
+
+	if before.AWSConfig.Region != after.AWSConfig.Region {
+		rejected = append(rejected, "aws.region")
+	}
+
+	oldES, newES := before.ElasticsearchConfig, after.ElasticsearchConfig
+	if oldES.CloudID != newES.CloudID || !slices.Equal(oldES.Addresses, newES.Addresses) ||
+		oldES.APIKey != newES.APIKey || oldES.Username != newES.Username || oldES.Password != newES.Password ||
+		!slices.Equal(oldES.CACert, newES.CACert) {
+		rejected = append(rejected, "elasticsearch")
+	}
+
+	if !reflect.DeepEqual(before.Topics, after.Topics) {
+		rejected = append(rejected, "topics")
+	}
+
+	if !reflect.DeepEqual(before.EncodingConfig, after.EncodingConfig) {
+		rejected = append(rejected, "encoding")
+	}
+
+	if !reflect.DeepEqual(before.SchemaRegistryConfig, after.SchemaRegistryConfig) {
+		rejected = append(rejected, "schema_registry")
+	}
+
+	rejected = append(rejected, rejectedFields("ProducerConfig", before.ProducerConfig, after.ProducerConfig)...)
+	rejected = append(rejected, rejectedFields("ConsumerConfig", before.ConsumerConfig, after.ConsumerConfig)...)
+
+	return rejected
+}
+
+// rejectedFields lists the fields of two settings structs that differ but are not live.
+func rejectedFields(section string, before, after any) []string {
+	var rejected []string
+
+	oldValue, newValue := reflect.ValueOf(before).Elem(), reflect.ValueOf(after).Elem()
+
+	for i := 0; i < oldValue.NumField(); i++ {
+		name := section + "." + oldValue.Type().Field(i).Name
+
+		if !slices.Contains(liveSettings, name) && !reflect.DeepEqual(oldValue.Field(i).Interface(), newValue.Field(i).Interface()) {
+			rejected = append(rejected, name)
+		}
+	}
+
+	return rejected
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+const watchedConfig = `
+[aws]
+region = "eu-west-1"
+access_key_id = "test"
+
+[kafka]
+bootstrap.servers = "localhost:9092"
+
+[kafka.consumer]
+group.id = "test"
+
+[producer]
+interval = "3s"
+
+[consumer.sinks]
+football-match-new = ["archive"]
+`
+
+func TestRejectedSettings(t *testing.T) {
+	file := writeConfigFile(t, watchedConfig)
+
+	before, err := Load(Options{File: file})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides []string
+		want      []string
+	}{
+		{"no change", nil, nil},
+		{"live settings", []string{"producer.interval=1s", "consumer.commit.batch_size=10", "consumer.archive.max_file_age=1m"}, nil},
+		{"shared Kafka setting", []string{"kafka.bootstrap.servers=broker:9092"}, []string{"kafka.bootstrap.servers"}},
+		{"client Kafka setting", []string{"kafka.consumer.group.id=other"}, []string{"kafka.consumer.group.id"}},
+		{"producer profile", []string{"kafka.producer.profile=low-latency"}, []string{
+			"kafka.producer.acks", "kafka.producer.batch.num.messages", "kafka.producer.compression.type",
+			"kafka.producer.linger.ms", "kafka.producer.socket.nagle.disable",
+		}},
+		{"aws region", []string{"aws.region=us-east-1"}, []string{"aws.region"}},
+		{"elasticsearch", []string{"elasticsearch.addresses=http://localhost:9200"}, []string{"elasticsearch"}},
+		{"encoding", []string{"encoding.default=protobuf"}, []string{"encoding"}},
+		{"schema registry", []string{"schema_registry.url=http://localhost:8081"}, []string{"schema_registry"}},
+		{"restart settings", []string{"producer.http.address=:9090", "consumer.dynamodb.table=Other"}, []string{
+			"ProducerConfig.HTTPAddress", "ConsumerConfig.DynamoDBTable",
+		}},
+	}
+
+	for _, tt := range tests {
+		after, err := Load(Options{File: file, Overrides: tt.overrides})
+		if err != nil {
+			t.Fatalf("%s: Load() error = %v", tt.name, err)
+		}
+
+		if got := rejectedSettings(before, after); !slices.Equal(got, tt.want) {
+			t.Errorf("%s: rejectedSettings() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	file := writeConfigFile(t, watchedConfig)
+	opts := Options{File: file}
+
+	current, err := Load(opts)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	w := NewWatcher(opts, current, ValidateOptions{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	var changes []Change
+	w.Subscribe(func(change Change) {
+		changes = append(changes, change)
+	})
+
+	rewrite := func(contents string) {
+		if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		w.reload()
+	}
+
+	// a config that fails to load or validate is ignored and the current one kept
+	rewrite(watchedConfig + "\n[log]\nlevel = \"verbose\"\n")
+	rewrite(watchedConfig + "\n[consumer]\nfailure_policy = \"retry\"\n")
+
+	if len(changes) != 0 {
+		t.Fatalf("subscribers were called with an invalid config: %+v", changes)
+	}
+
+	rewrite(watchedConfig + "\n[encoding]\ndefault = \"protobuf\"\n")
+	rewrite(strings.Replace(watchedConfig, `interval = "3s"`, `interval = "1s"`, 1))
+
+	if len(changes) != 2 {
+		t.Fatalf("subscribers were called %d times, want 2", len(changes))
+	}
+
+	if changes[0].Old != current || !slices.Equal(changes[0].Rejected, []string{"encoding"}) {
+		t.Errorf("first change = %+v, want a change from the loaded config rejecting encoding", changes[0])
+	}
+
+	// changes are relative to the last config applied, so going back to the default encoding is rejected too
+	if changes[1].Old != changes[0].New || !slices.Equal(changes[1].Rejected, []string{"encoding"}) {
+		t.Errorf("second change = %+v, want a change from the first one rejecting encoding", changes[1])
+	}
+
+	if interval := changes[1].New.ProducerConfig.Interval; interval != time.Second {
+		t.Errorf("producer.interval = %s, want 1s", interval)
+	}
+}
@@ -0,0 +1,183 @@
+package scenario
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// Scenario is a scripted storyline of football matches and the events that happen in them.
+//
+//	name: Arsenal vs Chelsea, abandoned
+//	minute: 1s
+//	matches:
+//	  - competition: Premier League
+//	    home: Arsenal F.C.
+//	    away: Chelsea F.C.
+//	    round: 12
+//	    kick_off: T+10s
+//	    events:
+//	      - {minute: 0, type: kick_off}
+//	      - {minute: 23, type: goal, team: home, player: Saka}
+//	      - {minute: 55, type: red_card, team: away}
+//	      - {minute: 70, type: abandoned}
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Minute is how much wall-clock time one match minute takes, defaults to one real minute.
+	Minute  Duration `yaml:"minute"`
+	Matches []Match  `yaml:"matches"`
+}
+
+// Match scripts one football match. Teams are referred to by name within the competition.
+type Match struct {
+	Competition string `yaml:"competition"`
+	Home        string `yaml:"home"`
+	Away        string `yaml:"away"`
+	Stadium     string `yaml:"stadium"`
+	Round       int    `yaml:"round"`
+	// Announce is when the match is published, defaults to the start of the scenario.
+	Announce *At     `yaml:"announce"`
+	KickOff  At      `yaml:"kick_off"`
+	Events   []Event `yaml:"events"`
+}
+
+// Event scripts something that happens in a match, either at a match minute or at an explicit time.
+type Event struct {
+	Type   sports.FootballMatchEventType `yaml:"type"`
+	Minute int                           `yaml:"minute"`
+	At     *At                           `yaml:"at"`
+	// Team is "home", "away" or the name of either team.
+	Team   string `yaml:"team"`
+	Player string `yaml:"player"`
+}
+
+// Step is a single publication in a scenario's timeline: either a match or a match event.
+type Step struct {
+	At    time.Time
+	Match *sports.FootballMatch
+	Event *sports.FootballMatchEvent
+}
+
+// Load reads and parses a scenario file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(Scenario)
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(s); err != nil {
+		return nil, fmt.Errorf("Failed to parse scenario %s: %w", path, err)
+	}
+
+	if s.Name == "" {
+		s.Name = path
+	}
+
+	if s.Minute.Duration == 0 {
+		s.Minute.Duration = time.Minute
+	}
+
+	return s, nil
+}
+
+// Timeline resolves the scenario against its start time into the ordered list of publications,
+// validating every match and event against the sports rules.
+func (s *Scenario) Timeline(start time.Time) ([]Step, error) {
+	var (
+		steps []Step
+		errs  []error
+	)
+
+	for i, m := range s.Matches {
+		matchSteps, err := m.timeline(start, s.Minute.Duration)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("match %d (%s vs %s): %w", i+1, m.Home, m.Away, err))
+
+			continue
+		}
+
+		steps = append(steps, matchSteps...)
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(steps, func(a, b Step) int {
+		return a.At.Compare(b.At)
+	})
+
+	return steps, nil
+}
+
+func (m *Match) timeline(start time.Time, minute time.Duration) ([]Step, error) {
+	kickOff := m.KickOff.Resolve(start)
+
+	fm := &sports.FootballMatch{
+		HomeTeam:    &sports.FootballTeam{Name: m.Home},
+		AwayTeam:    &sports.FootballTeam{Name: m.Away},
+		Stadium:     m.Stadium,
+		Round:       m.Round,
+		Competition: m.Competition,
+		KickOff:     kickOff,
+	}
+
+	fm.Complete()
+
+	if err := fm.Validate(); err != nil {
+		return nil, err
+	}
+
+	announce := start
+	if m.Announce != nil {
+		announce = m.Announce.Resolve(start)
+	}
+
+	steps := []Step{{At: announce, Match: fm}}
+
+	var errs []error
+
+	for _, e := range m.Events {
+		ev := sports.NewFootballMatchEvent(fm, e.Type, e.Minute)
+		ev.Player = e.Player
+
+		switch e.Team {
+		case "":
+		case "home", fm.HomeTeam.Name:
+			ev.Team = fm.HomeTeam
+		case "away", fm.AwayTeam.Name:
+			ev.Team = fm.AwayTeam
+		default:
+			ev.Team = &sports.FootballTeam{Name: e.Team}
+		}
+
+		if err := ev.Validate(fm); err != nil {
+			errs = append(errs, fmt.Errorf("%s at minute %d: %w", e.Type, e.Minute, err))
+
+			continue
+		}
+
+		at := kickOff.Add(time.Duration(e.Minute) * minute)
+		if e.At != nil {
+			at = e.At.Resolve(start)
+		}
+
+		ev.OccurredAt = at
+
+		steps = append(steps, Step{At: at, Event: ev})
+	}
+
+	return steps, errors.Join(errs...)
+}
@@ -0,0 +1,200 @@
+package scenario
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+func writeScenario(t *testing.T, contents string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("Failed to write scenario: %v", err)
+	}
+
+	return path
+}
+
+func TestAt(t *testing.T) {
+	start := time.Date(2026, 10, 24, 14, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{value: "T+10s", want: start.Add(10 * time.Second)},
+		{value: "T+1h30m", want: start.Add(90 * time.Minute)},
+		{value: "2026-10-24T15:00:00Z", want: time.Date(2026, 10, 24, 15, 0, 0, 0, time.UTC)},
+		{value: "2026-10-24T17:00:00+02:00", want: time.Date(2026, 10, 24, 15, 0, 0, 0, time.UTC)},
+		{value: "T+ten seconds", wantErr: true},
+		{value: "24/10/2026 15:00", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			var at At
+
+			err := yaml.Unmarshal([]byte(tt.value), &at)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Unmarshal() = %+v, want an error", at)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+
+			if got := at.Resolve(start); !got.Equal(tt.want) {
+				t.Errorf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	s, err := Load(writeScenario(t, `
+matches:
+  - {competition: Premier League, home: Arsenal F.C., away: Chelsea F.C., round: 1, kick_off: T+0s}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if !strings.HasSuffix(s.Name, "scenario.yaml") || s.Minute.Duration != time.Minute {
+		t.Errorf("Load() = name %q minute %s, want the file name and one minute", s.Name, s.Minute)
+	}
+
+	if _, err := Load(writeScenario(t, "name: typo\nmatchs: []\n")); err == nil {
+		t.Error("Load() of a scenario with an unknown field error = nil, want an error")
+	}
+}
+
+func TestTimeline(t *testing.T) {
+	s, err := Load(writeScenario(t, `
+name: two matches
+minute: 1s
+matches:
+  - competition: Premier League
+    home: Arsenal F.C.
+    away: Chelsea F.C.
+    round: 12
+    kick_off: T+10s
+    events:
+      - {minute: 70, type: abandoned}
+      - {minute: 0, type: kick_off}
+      - {minute: 23, type: goal, team: home, player: Bukayo Saka}
+  - competition: La Liga
+    home: Real Madrid
+    away: Barcelona
+    round: 5
+    announce: T+5s
+    kick_off: T+20s
+    events:
+      - {type: postponed, at: "2026-10-24T14:00:15Z"}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	start := time.Date(2026, 10, 24, 14, 0, 0, 0, time.UTC)
+
+	steps, err := s.Timeline(start)
+	if err != nil {
+		t.Fatalf("Timeline() error = %v", err)
+	}
+
+	type step struct {
+		offset time.Duration
+		what   string
+	}
+
+	want := []step{
+		{0, "Arsenal F.C."},
+		{5 * time.Second, "Real Madrid"},
+		{10 * time.Second, string(sports.FootballMatchEventKickOff)},
+		{15 * time.Second, string(sports.FootballMatchEventPostponed)},
+		{33 * time.Second, string(sports.FootballMatchEventGoal)},
+		{80 * time.Second, string(sports.FootballMatchEventAbandoned)},
+	}
+
+	var got []step
+
+	for _, s := range steps {
+		what := ""
+		if s.Match != nil {
+			what = s.Match.HomeTeam.Name
+		} else {
+			what = string(s.Event.Type)
+		}
+
+		got = append(got, step{s.At.Sub(start), what})
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Timeline() = %v, want %v", got, want)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Timeline()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+
+	arsenal, goal := steps[0].Match, steps[4].Event
+	if goal.MatchID != arsenal.ID || goal.Team != arsenal.HomeTeam || !goal.OccurredAt.Equal(start.Add(33*time.Second)) {
+		t.Errorf("goal = %+v, want Arsenal's goal in match %s at T+33s", goal, arsenal.ID)
+	}
+}
+
+func TestTimelineValidation(t *testing.T) {
+	s, err := Load(writeScenario(t, `
+matches:
+  - competition: Premier League
+    home: Arsenal F.C.
+    away: Real Madrid CF
+    round: 1
+    kick_off: T+0s
+  - competition: Premier League
+    home: Arsenal F.C.
+    away: Chelsea F.C.
+    round: 1
+    kick_off: T+0s
+    events:
+      - {minute: 10, type: goal}
+      - {minute: 20, type: goal, team: Liverpool F.C.}
+      - {minute: 30, type: corner}
+      - {minute: 45, type: half_time, team: home}
+`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	_, err = s.Timeline(time.Now())
+	if err == nil {
+		t.Fatal("Timeline() error = nil, want validation errors")
+	}
+
+	for _, want := range []string{
+		`match 1 (Arsenal F.C. vs Real Madrid CF): away_team "Real Madrid CF" does not play in Premier League`,
+		"goal at minute 10: goal requires a team",
+		`goal at minute 20: team "Liverpool F.C." does not play in this match`,
+		`corner at minute 30: unknown event type "corner"`,
+		"half_time at minute 45: half_time cannot have a team",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Timeline() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package scenario
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Duration is a time.Duration written as a Go duration string, such as "1s" or "250ms".
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	duration, err := time.ParseDuration(node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: invalid duration %q", node.Line, node.Value)
+	}
+
+	d.Duration = duration
+
+	return nil
+}
+
+// At is a point in time written either relative to the start of the scenario, as "T+10s",
+// or as an absolute wall-clock time in RFC 3339 format, as "2026-10-24T15:00:00Z".
+type At struct {
+	Offset   time.Duration
+	Absolute time.Time
+}
+
+func (a *At) UnmarshalYAML(node *yaml.Node) error {
+	if offset, ok := strings.CutPrefix(node.Value, "T+"); ok {
+		duration, err := time.ParseDuration(offset)
+		if err != nil {
+			return fmt.Errorf("line %d: invalid relative time %q", node.Line, node.Value)
+		}
+
+		a.Offset = duration
+
+		return nil
+	}
+
+	absolute, err := time.Parse(time.RFC3339, node.Value)
+	if err != nil {
+		return fmt.Errorf("line %d: time %q is neither T+<duration> nor RFC 3339", node.Line, node.Value)
+	}
+
+	a.Absolute = absolute
+
+	return nil
+}
+
+// Resolve returns the wall-clock time of a, given the start of the scenario.
+func (a At) Resolve(start time.Time) time.Time {
+	if !a.Absolute.IsZero() {
+		return a.Absolute
+	}
+
+	return start.Add(a.Offset)
+}
@@ -0,0 +1,155 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const contentType = "application/vnd.schemaregistry.v1+json"
+
+// Config holds the connection settings of a Confluent-compatible schema registry.
+type Config struct {
+	URL      string
+	Username string
+	Password string
+}
+
+// Client talks to a Confluent-compatible schema registry over its REST API.
+// Registered schema IDs and schemas looked up by ID are cached, as both are immutable.
+type Client struct {
+	cfg        Config
+	httpClient *http.Client
+
+	mu           sync.RWMutex
+	idsBySubject map[string]map[string]int
+	schemasByID  map[int]string
+}
+
+// Error is returned when the schema registry responds with a non-2xx status code.
+type Error struct {
+	StatusCode int
+	Code       int    `json:"error_code"`
+	Message    string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("schema registry error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// NewClient creates a new Client for the registry described by cfg.
+func NewClient(cfg Config) *Client {
+	cfg.URL = strings.TrimSuffix(cfg.URL, "/")
+
+	return &Client{
+		cfg:          cfg,
+		httpClient:   &http.Client{Timeout: 10 * time.Second},
+		idsBySubject: make(map[string]map[string]int),
+		schemasByID:  make(map[int]string),
+	}
+}
+
+// Register registers the Avro schema under the subject and returns its global ID.
+// Registering a schema that already exists under the subject is a no-op that returns the existing ID.
+func (c *Client) Register(ctx context.Context, subject, schema string) (int, error) {
+	c.mu.RLock()
+	id, ok := c.idsBySubject[subject][schema]
+	c.mu.RUnlock()
+
+	if ok {
+		return id, nil
+	}
+
+	var rsp struct {
+		ID int `json:"id"`
+	}
+
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := c.do(ctx, http.MethodPost, path, map[string]string{"schema": schema}, &rsp); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	if c.idsBySubject[subject] == nil {
+		c.idsBySubject[subject] = make(map[string]int)
+	}
+	c.idsBySubject[subject][schema] = rsp.ID
+	c.schemasByID[rsp.ID] = schema
+	c.mu.Unlock()
+
+	return rsp.ID, nil
+}
+
+// SchemaByID returns the schema registered under the given global ID.
+func (c *Client) SchemaByID(ctx context.Context, id int) (string, error) {
+	c.mu.RLock()
+	schema, ok := c.schemasByID[id]
+	c.mu.RUnlock()
+
+	if ok {
+		return schema, nil
+	}
+
+	var rsp struct {
+		Schema string `json:"schema"`
+	}
+
+	if err := c.do(ctx, http.MethodGet, "/schemas/ids/"+strconv.Itoa(id), nil, &rsp); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.schemasByID[id] = rsp.Schema
+	c.mu.Unlock()
+
+	return rsp.Schema, nil
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	var reqBody bytes.Buffer
+
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.cfg.URL+path, &reqBody)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", contentType)
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+
+	rsp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		regErr := &Error{StatusCode: rsp.StatusCode}
+
+		// the body is best effort, the status code alone is enough to report the failure
+		_ = json.NewDecoder(rsp.Body).Decode(regErr)
+
+		return regErr
+	}
+
+	return json.NewDecoder(rsp.Body).Decode(out)
+}
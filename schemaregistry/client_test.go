@@ -0,0 +1,138 @@
+package schemaregistry_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry/schemaregistrytest"
+)
+
+const (
+	schemaV1 = `{"type":"record","name":"Match","fields":[{"name":"id","type":"string"}]}`
+	schemaV2 = `{"type":"record","name":"Match","fields":[{"name":"id","type":"string"},{"name":"round","type":"int","default":0}]}`
+)
+
+func TestRegister(t *testing.T) {
+	server := schemaregistrytest.NewServer()
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL + "/"})
+	ctx := context.Background()
+
+	id1, err := client.Register(ctx, "matches-value", schemaV1)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	id2, err := client.Register(ctx, "matches-value", schemaV2)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if id1 == id2 {
+		t.Errorf("Register() returned ID %d for two different schemas", id1)
+	}
+
+	// the same schema under another subject keeps its global ID
+	id3, err := client.Register(ctx, "archive-value", schemaV1)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if id3 != id1 {
+		t.Errorf("Register() under another subject = %d, want %d", id3, id1)
+	}
+
+	if got := server.Subject("matches-value"); len(got) != 2 || got[0] != id1 || got[1] != id2 {
+		t.Errorf("subject matches-value has versions %v, want [%d %d]", got, id1, id2)
+	}
+}
+
+func TestRegisterCachesIDs(t *testing.T) {
+	server := schemaregistrytest.NewServer()
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL})
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.Register(ctx, "matches-value", schemaV1); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	if got := server.Requests(); got != 1 {
+		t.Errorf("registry answered %d requests, want 1", got)
+	}
+}
+
+func TestSchemaByID(t *testing.T) {
+	server := schemaregistrytest.NewServer()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	id, err := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL}).Register(ctx, "matches-value", schemaV1)
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	// a fresh client has nothing cached, as a consumer would
+	client := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL})
+	requests := server.Requests()
+
+	for i := 0; i < 3; i++ {
+		schema, err := client.SchemaByID(ctx, id)
+		if err != nil {
+			t.Fatalf("SchemaByID() error = %v", err)
+		}
+
+		if schema != schemaV1 {
+			t.Errorf("SchemaByID() = %s, want %s", schema, schemaV1)
+		}
+	}
+
+	if got := server.Requests() - requests; got != 1 {
+		t.Errorf("registry answered %d lookups, want 1", got)
+	}
+}
+
+func TestSchemaByIDNotFound(t *testing.T) {
+	server := schemaregistrytest.NewServer()
+	defer server.Close()
+
+	_, err := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL}).SchemaByID(context.Background(), 42)
+
+	var regErr *schemaregistry.Error
+	if !errors.As(err, &regErr) {
+		t.Fatalf("SchemaByID() error = %v, want a *schemaregistry.Error", err)
+	}
+
+	if regErr.StatusCode != http.StatusNotFound || regErr.Code != 40403 {
+		t.Errorf("SchemaByID() error = %v, want HTTP 404 with code 40403", regErr)
+	}
+}
+
+func TestBasicAuth(t *testing.T) {
+	var username, password string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ = r.BasicAuth()
+		_, _ = w.Write([]byte(`{"id":1}`))
+	}))
+	defer server.Close()
+
+	client := schemaregistry.NewClient(schemaregistry.Config{URL: server.URL, Username: "key", Password: "secret"})
+
+	if _, err := client.Register(context.Background(), "matches-value", schemaV1); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+
+	if username != "key" || password != "secret" {
+		t.Errorf("registry got basic auth %q:%q, want key:secret", username, password)
+	}
+}
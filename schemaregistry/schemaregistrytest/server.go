@@ -0,0 +1,117 @@
+// Package schemaregistrytest provides an in-process schema registry for tests.
+package schemaregistrytest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// Server is a fake Confluent-compatible schema registry that keeps its schemas in memory.
+// It implements registering a schema under a subject and looking a schema up by ID.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	ids      map[string]int
+	schemas  map[int]string
+	subjects map[string][]int
+	requests int
+}
+
+// NewServer starts a Server. Close it when the test is done.
+func NewServer() *Server {
+	s := &Server{
+		ids:      make(map[string]int),
+		schemas:  make(map[int]string),
+		subjects: make(map[string][]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /subjects/{subject}/versions", s.register)
+	mux.HandleFunc("GET /schemas/ids/{id}", s.schemaByID)
+
+	s.Server = httptest.NewServer(mux)
+
+	return s
+}
+
+// Requests returns the number of requests the server has answered.
+func (s *Server) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests
+}
+
+// Subject returns the IDs of the schemas registered under the subject, in registration order.
+func (s *Server) Subject(subject string) []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]int(nil), s.subjects[subject]...)
+}
+
+// register assigns every distinct schema a global ID, like the real registry does.
+func (s *Server) register(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Schema string `json:"schema"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusUnprocessableEntity, 42201, "Invalid schema")
+		return
+	}
+
+	subject := r.PathValue("subject")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	id, ok := s.ids[req.Schema]
+	if !ok {
+		id = len(s.ids) + 1
+		s.ids[req.Schema] = id
+		s.schemas[id] = req.Schema
+	}
+
+	if !slices.Contains(s.subjects[subject], id) {
+		s.subjects[subject] = append(s.subjects[subject], id)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"id": id})
+}
+
+func (s *Server) schemaByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+
+	schema, ok := s.schemas[id]
+	if err != nil || !ok {
+		writeError(w, http.StatusNotFound, 40403, "Schema not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"schema": schema})
+}
+
+func writeError(w http.ResponseWriter, status, code int, message string) {
+	writeJSON(w, status, map[string]any{"error_code": code, "message": message})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+
+	// the client sees a truncated body as a decode error
+	_ = json.NewEncoder(w).Encode(body)
+}
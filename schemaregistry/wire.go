@@ -0,0 +1,30 @@
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+// MagicByte prefixes every message value framed with the schema registry wire format.
+const MagicByte byte = 0
+
+// ErrInvalidFrame is returned when a message value does not use the schema registry wire format.
+var ErrInvalidFrame = errors.New("message is not framed with a schema ID")
+
+// Frame prefixes the payload with the magic byte and the big-endian schema ID.
+func Frame(id int, payload []byte) []byte {
+	framed := make([]byte, 5, 5+len(payload))
+	framed[0] = MagicByte
+	binary.BigEndian.PutUint32(framed[1:5], uint32(id))
+
+	return append(framed, payload...)
+}
+
+// Unframe splits a framed message value into its schema ID and payload.
+func Unframe(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != MagicByte {
+		return 0, nil, ErrInvalidFrame
+	}
+
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
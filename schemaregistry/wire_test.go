@@ -0,0 +1,41 @@
+package schemaregistry
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestFrame(t *testing.T) {
+	framed := Frame(0x01020304, []byte("payload"))
+
+	want := append([]byte{0x00, 0x01, 0x02, 0x03, 0x04}, "payload"...)
+	if !bytes.Equal(framed, want) {
+		t.Fatalf("Frame() = %x, want %x", framed, want)
+	}
+
+	id, payload, err := Unframe(framed)
+	if err != nil {
+		t.Fatalf("Unframe() error = %v", err)
+	}
+
+	if id != 0x01020304 || string(payload) != "payload" {
+		t.Errorf("Unframe() = %d, %q, want %d, %q", id, payload, 0x01020304, "payload")
+	}
+}
+
+func TestUnframeInvalid(t *testing.T) {
+	tests := map[string][]byte{
+		"empty":         nil,
+		"too short":     {0x00, 0x00, 0x00, 0x01},
+		"no magic byte": []byte(`{"id":"1"}`),
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, _, err := Unframe(data); !errors.Is(err, ErrInvalidFrame) {
+				t.Errorf("Unframe() error = %v, want ErrInvalidFrame", err)
+			}
+		})
+	}
+}
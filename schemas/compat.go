@@ -0,0 +1,85 @@
+package schemas
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Incompatibility describes one way in which two schema versions cannot read each other's events.
+type Incompatibility struct {
+	Path   string
+	Reason string
+}
+
+func (i Incompatibility) String() string {
+	return i.Path + ": " + i.Reason
+}
+
+// CheckCompatibility reports every change from old to new that breaks
+// backward compatibility (consumers on new cannot read events written with old) or
+// forward compatibility (consumers on old cannot read events written with new).
+func CheckCompatibility(old, new *Schema) []Incompatibility {
+	return compare("$", old, new)
+}
+
+func compare(path string, old, new *Schema) []Incompatibility {
+	if old.Type != new.Type {
+		return []Incompatibility{{path, fmt.Sprintf("type changed from %q to %q", old.Type, new.Type)}}
+	}
+
+	var incompatibilities []Incompatibility
+
+	if old.Format != new.Format {
+		incompatibilities = append(incompatibilities,
+			Incompatibility{path, fmt.Sprintf("format changed from %q to %q", old.Format, new.Format)})
+	}
+
+	if old.Items != nil && new.Items != nil {
+		incompatibilities = append(incompatibilities, compare(path+"[]", old.Items, new.Items)...)
+	}
+
+	for name, newProperty := range new.Properties {
+		propertyPath := path + "." + name
+
+		oldProperty, ok := old.Properties[name]
+		if !ok {
+			if slices.Contains(new.Required, name) {
+				incompatibilities = append(incompatibilities,
+					Incompatibility{propertyPath, "new required property breaks backward compatibility"})
+			}
+
+			continue
+		}
+
+		if slices.Contains(new.Required, name) && !slices.Contains(old.Required, name) {
+			// events written with old may omit the property
+			incompatibilities = append(incompatibilities,
+				Incompatibility{propertyPath, "optional property made required breaks backward compatibility"})
+		}
+
+		incompatibilities = append(incompatibilities, compare(propertyPath, oldProperty, newProperty)...)
+	}
+
+	for name := range old.Properties {
+		if _, ok := new.Properties[name]; !ok && !slices.Contains(old.Required, name) {
+			// events written with old still carry the property, and nothing would stop its name
+			// from being reused with another type
+			incompatibilities = append(incompatibilities,
+				Incompatibility{path + "." + name, "optional property removed breaks backward compatibility"})
+		}
+	}
+
+	for _, name := range old.Required {
+		if !slices.Contains(new.Required, name) {
+			incompatibilities = append(incompatibilities,
+				Incompatibility{path + "." + name, "required property removed or made optional breaks forward compatibility"})
+		}
+	}
+
+	slices.SortFunc(incompatibilities, func(a, b Incompatibility) int {
+		return strings.Compare(a.Path, b.Path)
+	})
+
+	return incompatibilities
+}
@@ -0,0 +1,118 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+//go:generate go run ../cmd/sportfeed schema check -dir json -write
+
+// Event is a versioned event contract whose JSON Schema is generated from a Go type.
+type Event struct {
+	Name    string
+	Title   string
+	Version int
+	Type    any
+}
+
+// Events lists every event contract published on Kafka.
+var Events = []Event{
+	{Name: "football_match", Title: "FootballMatch", Version: sports.FootballMatchSchemaVersion, Type: sports.FootballMatch{}},
+	{Name: "football_match_event", Title: "FootballMatchEvent", Version: sports.FootballMatchEventSchemaVersion, Type: sports.FootballMatchEvent{}},
+}
+
+// FileName returns the name of the JSON Schema file of the given version of the event.
+func (e Event) FileName(version int) string {
+	return fmt.Sprintf("%s.v%d.json", e.Name, version)
+}
+
+// Generate reflects the JSON Schema of the current version of the event.
+func (e Event) Generate() *Schema {
+	return Generate("https://github.com/tuannkhoi/sport-data-feed/schemas/json/"+e.FileName(e.Version), e.Title, e.Type)
+}
+
+// Write generates the JSON Schema of the current version of every event into dir.
+func Write(dir string) error {
+	for _, e := range Events {
+		data, err := marshal(e.Generate())
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, e.FileName(e.Version)), data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Check verifies that the committed JSON Schema of every event is up to date with its Go type,
+// and that the current version is backward and forward compatible with all previous versions.
+func Check(dir string) error {
+	var problems []string
+
+	for _, e := range Events {
+		current := e.Generate()
+
+		data, err := marshal(current)
+		if err != nil {
+			return err
+		}
+
+		committed, err := os.ReadFile(filepath.Join(dir, e.FileName(e.Version)))
+		if err != nil || !bytes.Equal(committed, data) {
+			problems = append(problems, fmt.Sprintf(
+				"%s: schema is out of date with the Go type, bump the schema version if the contract changed and run `go generate ./schemas`",
+				e.FileName(e.Version)))
+		}
+
+		for version := 1; version < e.Version; version++ {
+			previous, err := read(filepath.Join(dir, e.FileName(version)))
+			if err != nil {
+				return err
+			}
+
+			for _, incompatibility := range CheckCompatibility(previous, current) {
+				problems = append(problems, fmt.Sprintf("%s -> v%d: %s", e.FileName(version), e.Version, incompatibility))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.New(strings.Join(problems, "\n"))
+	}
+
+	return nil
+}
+
+func read(path string) (*Schema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := new(Schema)
+
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, fmt.Errorf("Failed to parse %s: %w", path, err)
+	}
+
+	return schema, nil
+}
+
+func marshal(schema *Schema) ([]byte, error) {
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
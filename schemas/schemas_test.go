@@ -0,0 +1,91 @@
+package schemas
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestCheck fails when a Go event type changes without its committed JSON Schema, or incompatibly with an earlier version.
+func TestCheck(t *testing.T) {
+	if err := Check("json"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	object := func(required []string, properties map[string]*Schema) *Schema {
+		return &Schema{Type: "object", Properties: properties, Required: required}
+	}
+
+	str := &Schema{Type: "string"}
+
+	tests := []struct {
+		name     string
+		old, new *Schema
+		want     []Incompatibility
+	}{
+		{
+			name: "unchanged",
+			old:  object([]string{"id"}, map[string]*Schema{"id": str}),
+			new:  object([]string{"id"}, map[string]*Schema{"id": str}),
+		},
+		{
+			name: "optional property added",
+			old:  object([]string{"id"}, map[string]*Schema{"id": str}),
+			new:  object([]string{"id"}, map[string]*Schema{"id": str, "stadium": str}),
+		},
+		{
+			name: "required property added",
+			old:  object([]string{"id"}, map[string]*Schema{"id": str}),
+			new:  object([]string{"id", "stadium"}, map[string]*Schema{"id": str, "stadium": str}),
+			want: []Incompatibility{{"$.stadium", "new required property breaks backward compatibility"}},
+		},
+		{
+			name: "optional property made required",
+			old:  object([]string{"id"}, map[string]*Schema{"id": str, "stadium": str}),
+			new:  object([]string{"id", "stadium"}, map[string]*Schema{"id": str, "stadium": str}),
+			want: []Incompatibility{{"$.stadium", "optional property made required breaks backward compatibility"}},
+		},
+		{
+			name: "optional property removed",
+			old:  object([]string{"id"}, map[string]*Schema{"id": str, "stadium": str}),
+			new:  object([]string{"id"}, map[string]*Schema{"id": str}),
+			want: []Incompatibility{{"$.stadium", "optional property removed breaks backward compatibility"}},
+		},
+		{
+			name: "required property removed",
+			old:  object([]string{"id", "stadium"}, map[string]*Schema{"id": str, "stadium": str}),
+			new:  object([]string{"id"}, map[string]*Schema{"id": str}),
+			want: []Incompatibility{{"$.stadium", "required property removed or made optional breaks forward compatibility"}},
+		},
+		{
+			name: "required property made optional",
+			old:  object([]string{"id", "stadium"}, map[string]*Schema{"id": str, "stadium": str}),
+			new:  object([]string{"id"}, map[string]*Schema{"id": str, "stadium": str}),
+			want: []Incompatibility{{"$.stadium", "required property removed or made optional breaks forward compatibility"}},
+		},
+		{
+			name: "nested type and format changed",
+			old: object(nil, map[string]*Schema{
+				"team":   object(nil, map[string]*Schema{"id": {Type: "string", Format: "uuid"}}),
+				"rounds": {Type: "array", Items: &Schema{Type: "integer"}},
+			}),
+			new: object(nil, map[string]*Schema{
+				"team":   object(nil, map[string]*Schema{"id": str}),
+				"rounds": {Type: "array", Items: str},
+			}),
+			want: []Incompatibility{
+				{"$.rounds[]", `type changed from "integer" to "string"`},
+				{"$.team.id", `format changed from "uuid" to ""`},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CheckCompatibility(tt.old, tt.new); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CheckCompatibility() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// States of a resource reported by the Ensure functions.
+const (
+	ResourceExists  = "exists"
+	ResourceCreated = "created"
+	ResourceMissing = "missing"
+)
+
+// Resource is a topic, table or index that the producer and consumer need.
+type Resource struct {
+	Kind  string
+	Name  string
+	State string
+}
+
+func (r Resource) String() string {
+	return fmt.Sprintf("%s %s %s", r.Kind, r.Name, r.State)
+}
+
+// Topics returns the names in Kafka of the topics that the producer and consumer use, including the dead-letter topic.
+func Topics(topicConfig *config.TopicConfig) []string {
+	return []string{
+		topicConfig.Name(sports.TopicNewFootballMatch),
+		topicConfig.Name(sports.TopicFootballMatchEvent),
+		topicConfig.Name(DeadLetterTopic(sports.TopicNewFootballMatch)),
+		topicConfig.Name(DeadLetterTopic(sports.TopicFootballMatchEvent)),
+	}
+}
+
+// EnsureTopics creates the missing topics of Topics with the given number of partitions and replication factor,
+// where -1 uses the broker default. With dryRun, missing topics are only reported.
+func EnsureTopics(ctx context.Context, cfg *config.Config, partitions, replicationFactor int, dryRun bool) ([]Resource, error) {
+	admin, err := kafka.NewAdminClient(cfg.KafkaConfigMap)
+	if err != nil {
+		return nil, errors.New("Failed to create AdminClient: " + err.Error())
+	}
+	defer admin.Close()
+
+	metadata, err := admin.GetMetadata(nil, true, 10*1000)
+	if err != nil {
+		return nil, errors.New("Failed to get topic metadata: " + err.Error())
+	}
+
+	var (
+		resources []Resource
+		missing   []kafka.TopicSpecification
+	)
+
+	for _, topic := range Topics(cfg.Topics) {
+		if _, ok := metadata.Topics[topic]; ok {
+			resources = append(resources, Resource{Kind: "topic", Name: topic, State: ResourceExists})
+
+			continue
+		}
+
+		resources = append(resources, Resource{Kind: "topic", Name: topic, State: ResourceMissing})
+		missing = append(missing, kafka.TopicSpecification{
+			Topic:             topic,
+			NumPartitions:     partitions,
+			ReplicationFactor: replicationFactor,
+		})
+	}
+
+	if dryRun || len(missing) == 0 {
+		return resources, nil
+	}
+
+	results, err := admin.CreateTopics(ctx, missing)
+	if err != nil {
+		return resources, errors.New("Failed to create topics: " + err.Error())
+	}
+
+	var errs []error
+
+	for _, result := range results {
+		// a topic created by someone else in the meantime is as good as one created here
+		if code := result.Error.Code(); code != kafka.ErrNoError && code != kafka.ErrTopicAlreadyExists {
+			errs = append(errs, fmt.Errorf("Failed to create topic %s: %w", result.Topic, result.Error))
+
+			continue
+		}
+
+		for i := range resources {
+			if resources[i].Name == result.Topic {
+				resources[i].State = ResourceCreated
+			}
+		}
+	}
+
+	return resources, errors.Join(errs...)
+}
+
+// EnsureTable creates the table of the dynamodb sink, keyed by match ID and billed per request, when it is missing.
+// With dryRun, a missing table is only reported.
+func EnsureTable(ctx context.Context, cfg *config.Config, dryRun bool) (Resource, error) {
+	client := newDynamoDBClient(cfg)
+	table := cfg.ConsumerConfig.DynamoDBTable
+
+	resource := Resource{Kind: "table", Name: table, State: ResourceExists}
+
+	_, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+
+	var notFound *types.ResourceNotFoundException
+	switch {
+	case err == nil:
+		return resource, nil
+	case !errors.As(err, &notFound):
+		return resource, errors.New("Failed to describe table: " + err.Error())
+	}
+
+	resource.State = ResourceMissing
+	if dryRun {
+		return resource, nil
+	}
+
+	_, err = client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName: aws.String(table),
+		AttributeDefinitions: []types.AttributeDefinition{
+			{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeS},
+		},
+		KeySchema: []types.KeySchemaElement{
+			{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+		},
+		BillingMode: types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		return resource, errors.New("Failed to create table: " + err.Error())
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)}, 2*time.Minute); err != nil {
+		return resource, errors.New("Failed waiting for table to become active: " + err.Error())
+	}
+
+	resource.State = ResourceCreated
+
+	return resource, nil
+}
+
+// EnsureIndex creates the index of the elasticsearch sink, with dynamic mappings, when it is missing.
+// With dryRun, a missing index is only reported.
+func EnsureIndex(ctx context.Context, cfg *config.Config, dryRun bool) (Resource, error) {
+	index := cfg.ConsumerConfig.ElasticsearchIndex
+
+	resource := Resource{Kind: "index", Name: index, State: ResourceExists}
+
+	client, err := elasticsearch.NewTypedClient(*cfg.ElasticsearchConfig)
+	if err != nil {
+		return resource, errors.New("Failed to create Elasticsearch client: " + err.Error())
+	}
+
+	exists, err := client.Indices.Exists(index).Do(ctx)
+	if err != nil {
+		return resource, errors.New("Failed to check index: " + err.Error())
+	}
+
+	if exists {
+		return resource, nil
+	}
+
+	resource.State = ResourceMissing
+	if dryRun {
+		return resource, nil
+	}
+
+	if _, err := client.Indices.Create(index).Do(ctx); err != nil {
+		return resource, errors.New("Failed to create index: " + err.Error())
+	}
+
+	resource.State = ResourceCreated
+
+	return resource, nil
+}
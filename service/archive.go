@@ -0,0 +1,436 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/google/uuid"
+	"github.com/xitongsys/parquet-go/writer"
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+func init() {
+	RegisterSink("archive", newArchiveSink, sports.TopicNewFootballMatch, sports.TopicFootballMatchEvent)
+}
+
+// unknownCompetition is the competition of records that do not carry theirs, such as match events.
+const unknownCompetition = "unknown"
+
+// archiveRowGroupSize bounds the rows that an open file buffers in memory before writing them out as a row group.
+const archiveRowGroupSize = 16 * 1024 * 1024
+
+// ArchiveStore keeps the complete files of the archive.
+type ArchiveStore interface {
+	// Put stores the body under the key, replacing any object with the same key.
+	Put(ctx context.Context, key string, body io.ReadSeeker) error
+}
+
+// S3ArchiveStore keeps the archive in an S3 bucket.
+type S3ArchiveStore struct {
+	Client *s3.Client
+	Bucket string
+}
+
+func (s *S3ArchiveStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	_, err := s.Client.PutObject(ctx, &s3.PutObjectInput{Bucket: &s.Bucket, Key: &key, Body: body})
+
+	return err
+}
+
+// LocalArchiveStore keeps the archive in a local directory. A file appears under its key only once it is complete.
+type LocalArchiveStore struct {
+	Dir string
+}
+
+func (s *LocalArchiveStore) Put(_ context.Context, key string, body io.ReadSeeker) error {
+	name := filepath.Join(s.Dir, filepath.FromSlash(key))
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(name), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, body); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
+
+// ArchiveManifest lists the files that a flush of the archive completed.
+// Downstream jobs should only read the files listed in a manifest, as the others may be partial or abandoned.
+type ArchiveManifest struct {
+	CreatedAt time.Time             `json:"created_at"`
+	Files     []ArchiveManifestFile `json:"files"`
+}
+
+type ArchiveManifestFile struct {
+	Key         string `json:"key"`
+	Topic       string `json:"topic"`
+	Sport       string `json:"sport"`
+	Competition string `json:"competition"`
+	Date        string `json:"date"`
+	Rows        int    `json:"rows"`
+	Bytes       int64  `json:"bytes"`
+	MinVersion  int64  `json:"min_version"`
+	MaxVersion  int64  `json:"max_version"`
+}
+
+// ArchiveSink archives every record as a row of a Parquet file, one open file per topic, sport, competition and date.
+// Files are staged locally and roll over once any of them reaches MaxFileBytes or has been open for MaxFileAge:
+// every open file is then completed, put in the store under Prefix, and listed in a new manifest.
+// The offsets of archived records are only committed once their file is listed, so records are held for
+// up to MaxFileAge and a restart redelivers the records of the files that were open.
+type ArchiveSink struct {
+	Store        ArchiveStore
+	Prefix       string
+	StagingDir   string
+	MaxFileBytes int64
+	MaxFileAge   time.Duration
+	Retry        RetryPolicy
+
+	files map[archivePartition]*archiveFile
+	rows  int
+}
+
+// archivePartition is where a record is archived: its files are kept under
+// <topic>/sport=<sport>/competition=<competition>/date=<yyyy-mm-dd>.
+type archivePartition struct {
+	topic       string
+	sport       string
+	competition string
+	date        string
+}
+
+func (ap archivePartition) path() string {
+	return fmt.Sprintf("%s/sport=%s/competition=%s/date=%s",
+		ap.topic, url.PathEscape(ap.sport), url.PathEscape(ap.competition), ap.date)
+}
+
+// archiveFile is the open file of a partition.
+type archiveFile struct {
+	name       string
+	staging    *os.File
+	writer     *writer.ParquetWriter
+	keys       []string
+	minVersion int64
+	maxVersion int64
+	opened     time.Time
+}
+
+// newArchiveSink archives records to the configured S3 bucket, or to a local directory when no bucket is set.
+func newArchiveSink(cfg *config.Config, _ *slog.Logger) (Sink, error) {
+	var store ArchiveStore = &LocalArchiveStore{Dir: cfg.ConsumerConfig.ArchiveDir}
+
+	if bucket := cfg.ConsumerConfig.ArchiveBucket; bucket != "" {
+		client := s3.NewFromConfig(*cfg.AWSConfig, func(o *s3.Options) {
+			// S3-compatible stores are usually only reachable with path-style addressing
+			if endpoint := cfg.ConsumerConfig.ArchiveEndpoint; endpoint != "" {
+				o.BaseEndpoint = &endpoint
+				o.UsePathStyle = true
+			}
+		})
+
+		store = &S3ArchiveStore{Client: client, Bucket: bucket}
+	}
+
+	stagingDir, err := os.MkdirTemp(cfg.ConsumerConfig.ArchiveStagingDir, "sportfeed-archive-")
+	if err != nil {
+		return nil, errors.New("Failed to create archive staging directory: " + err.Error())
+	}
+
+	return NewArchiveSink(
+		store,
+		cfg.ConsumerConfig.ArchivePrefix,
+		stagingDir,
+		cfg.ConsumerConfig.ArchiveMaxFileBytes,
+		cfg.ConsumerConfig.ArchiveMaxFileAge,
+		newRetryPolicy(cfg.ConsumerConfig),
+	), nil
+}
+
+// NewArchiveSink creates an archive sink staging its open files in stagingDir, which it removes on Close.
+func NewArchiveSink(
+	store ArchiveStore,
+	prefix string,
+	stagingDir string,
+	maxFileBytes int64,
+	maxFileAge time.Duration,
+	retry RetryPolicy,
+) *ArchiveSink {
+	return &ArchiveSink{
+		Store:        store,
+		Prefix:       prefix,
+		StagingDir:   stagingDir,
+		MaxFileBytes: maxFileBytes,
+		MaxFileAge:   maxFileAge,
+		Retry:        retry,
+		files:        make(map[archivePartition]*archiveFile),
+	}
+}
+
+// Write appends the record to the open file of its partition.
+func (as *ArchiveSink) Write(record *Record) error {
+	partition, row, err := archiveRow(record)
+	if err != nil {
+		return err
+	}
+
+	file, ok := as.files[partition]
+	if !ok {
+		if file, err = as.open(row); err != nil {
+			return errors.New("Failed to open archive file: " + err.Error())
+		}
+
+		as.files[partition] = file
+	}
+
+	if err := file.writer.Write(row); err != nil {
+		return errors.New("Failed to write archive row: " + err.Error())
+	}
+
+	if len(file.keys) == 0 || record.Version < file.minVersion {
+		file.minVersion = record.Version
+	}
+
+	file.maxVersion = max(file.maxVersion, record.Version)
+	file.keys = append(file.keys, record.Key)
+	as.rows++
+
+	return nil
+}
+
+// archiveRow returns the partition of a record and its Parquet row.
+func archiveRow(record *Record) (archivePartition, any, error) {
+	switch value := record.Value.(type) {
+	case *sports.FootballMatch:
+		return archivePartition{
+			topic:       record.Topic,
+			sport:       sports.SportFootball,
+			competition: value.Competition,
+			date:        value.UpdatedAt.UTC().Format(time.DateOnly),
+		}, value.ToParquetRow(), nil
+	case *sports.FootballMatchEvent:
+		return archivePartition{
+			topic:       record.Topic,
+			sport:       sports.SportFootball,
+			competition: unknownCompetition,
+			date:        value.OccurredAt.UTC().Format(time.DateOnly),
+		}, value.ToParquetRow(), nil
+	}
+
+	return archivePartition{}, nil, fmt.Errorf("%w for the archive: %T", ErrUnsupportedRecord, record.Value)
+}
+
+// open creates a staged file with the schema of the row.
+func (as *ArchiveSink) open(row any) (*archiveFile, error) {
+	name := fmt.Sprintf("part-%d-%s.parquet", time.Now().UnixMilli(), uuid.NewString())
+
+	staging, err := os.Create(filepath.Join(as.StagingDir, name))
+	if err != nil {
+		return nil, err
+	}
+
+	pw, err := writer.NewParquetWriterFromWriter(staging, row, 1)
+	if err != nil {
+		staging.Close()
+		os.Remove(staging.Name())
+
+		return nil, err
+	}
+
+	pw.RowGroupSize = archiveRowGroupSize
+
+	return &archiveFile{name: name, staging: staging, writer: pw, opened: time.Now()}, nil
+}
+
+// size estimates the size of the file once completed: the row groups written out and the rows still buffered.
+func (af *archiveFile) size() int64 {
+	return af.writer.Offset + af.writer.Size + af.writer.ObjsSize
+}
+
+// Pending returns the number of rows in the open files.
+func (as *ArchiveSink) Pending() int {
+	return as.rows
+}
+
+// Due reports whether any open file has reached MaxFileBytes or MaxFileAge.
+func (as *ArchiveSink) Due() bool {
+	for _, file := range as.files {
+		if file.size() >= as.MaxFileBytes || time.Since(file.opened) >= as.MaxFileAge {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Flush completes every open file, puts it in the store and lists the files that were put in a new manifest.
+// Puts are retried on transient errors with backoff. The records of a file that is not listed are reported as failed.
+func (as *ArchiveSink) Flush(ctx context.Context) error {
+	if len(as.files) == 0 {
+		return nil
+	}
+
+	defer as.Discard()
+
+	manifest := ArchiveManifest{CreatedAt: time.Now().UTC()}
+	failed := make(map[string]error)
+
+	var listed []string
+
+	partitions := maps.Keys(as.files)
+
+	slices.SortFunc(partitions, func(a, b archivePartition) int {
+		return strings.Compare(a.path(), b.path())
+	})
+
+	for _, partition := range partitions {
+		file := as.files[partition]
+
+		entry, err := as.complete(ctx, partition, file)
+		if err != nil {
+			for _, key := range file.keys {
+				failed[key] = err
+			}
+
+			continue
+		}
+
+		manifest.Files = append(manifest.Files, entry)
+		listed = append(listed, file.keys...)
+	}
+
+	if len(manifest.Files) > 0 {
+		if err := as.putManifest(ctx, &manifest); err != nil {
+			for _, key := range listed {
+				failed[key] = err
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return &BatchError{Sink: "archive", Failed: failed}
+	}
+
+	return nil
+}
+
+// complete writes the footer of the staged file and puts it in the store.
+func (as *ArchiveSink) complete(ctx context.Context, partition archivePartition, file *archiveFile) (ArchiveManifestFile, error) {
+	if err := file.writer.WriteStop(); err != nil {
+		return ArchiveManifestFile{}, errors.New("Failed to complete archive file: " + err.Error())
+	}
+
+	info, err := file.staging.Stat()
+	if err != nil {
+		return ArchiveManifestFile{}, err
+	}
+
+	key := path.Join(as.Prefix, partition.path(), file.name)
+
+	err = as.Retry.Do(ctx, func(ctx context.Context) error {
+		if _, err := file.staging.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		return as.Store.Put(ctx, key, file.staging)
+	})
+	if err != nil {
+		return ArchiveManifestFile{}, fmt.Errorf("Failed to put archive file %s: %w", key, err)
+	}
+
+	return ArchiveManifestFile{
+		Key:         key,
+		Topic:       partition.topic,
+		Sport:       partition.sport,
+		Competition: partition.competition,
+		Date:        partition.date,
+		Rows:        len(file.keys),
+		Bytes:       info.Size(),
+		MinVersion:  file.minVersion,
+		MaxVersion:  file.maxVersion,
+	}, nil
+}
+
+// putManifest puts the manifest under _manifests/date=<yyyy-mm-dd>, so that downstream jobs can list new manifests by day.
+func (as *ArchiveSink) putManifest(ctx context.Context, manifest *ArchiveManifest) error {
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return errors.New("Failed to marshal archive manifest: " + err.Error())
+	}
+
+	key := path.Join(as.Prefix, "_manifests", "date="+manifest.CreatedAt.Format(time.DateOnly),
+		fmt.Sprintf("%d-%s.json", manifest.CreatedAt.UnixMilli(), uuid.NewString()))
+
+	err = as.Retry.Do(ctx, func(ctx context.Context) error {
+		return as.Store.Put(ctx, key, bytes.NewReader(body))
+	})
+	if err != nil {
+		return fmt.Errorf("Failed to put archive manifest %s: %w", key, err)
+	}
+
+	return nil
+}
+
+// Discard drops every open file.
+func (as *ArchiveSink) Discard() {
+	for _, file := range as.files {
+		file.staging.Close()
+		os.Remove(file.staging.Name())
+	}
+
+	as.files = make(map[archivePartition]*archiveFile)
+	as.rows = 0
+}
+
+// Fork returns an archive sink with open files of its own, in the same store and staging directory.
+func (as *ArchiveSink) Fork() Sink {
+	return NewArchiveSink(as.Store, as.Prefix, as.StagingDir, as.MaxFileBytes, as.MaxFileAge, as.Retry)
+}
+
+// Reconfigure applies the rollover thresholds of the consumer settings.
+func (as *ArchiveSink) Reconfigure(consumerConfig *config.ConsumerConfig) {
+	as.MaxFileBytes = consumerConfig.ArchiveMaxFileBytes
+	as.MaxFileAge = consumerConfig.ArchiveMaxFileAge
+}
+
+// Close drops every open file and removes the staging directory.
+func (as *ArchiveSink) Close() error {
+	as.Discard()
+
+	return os.RemoveAll(as.StagingDir)
+}
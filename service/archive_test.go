@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xitongsys/parquet-go-source/buffer"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// failingStore puts files in a local directory, except those whose key contains failKey.
+type failingStore struct {
+	LocalArchiveStore
+	failKey string
+}
+
+func (s *failingStore) Put(ctx context.Context, key string, body io.ReadSeeker) error {
+	if strings.Contains(key, s.failKey) {
+		return errors.New("access denied")
+	}
+
+	return s.LocalArchiveStore.Put(ctx, key, body)
+}
+
+func newTestArchiveSink(t *testing.T, store ArchiveStore) *ArchiveSink {
+	t.Helper()
+
+	as := NewArchiveSink(store, "archive", t.TempDir(), 1<<20, time.Hour, RetryPolicy{MaxAttempts: 1})
+	t.Cleanup(func() { as.Close() })
+
+	return as
+}
+
+// newTestMatch returns a match of the competition, updated at 2024-05-01.
+func newTestMatch(competition string) *sports.FootballMatch {
+	fm := sports.NewFootballMatch()
+	fm.Competition = competition
+	fm.UpdatedAt = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	return fm
+}
+
+// readManifests returns every manifest the store holds.
+func readManifests(t *testing.T, dir string) []ArchiveManifest {
+	t.Helper()
+
+	var manifests []ArchiveManifest
+
+	names, _ := filepath.Glob(filepath.Join(dir, "archive", "_manifests", "date=*", "*.json"))
+
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var manifest ArchiveManifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			t.Fatalf("manifest %s: %v", name, err)
+		}
+
+		manifests = append(manifests, manifest)
+	}
+
+	return manifests
+}
+
+// storedFiles returns the keys of the Parquet files the store holds.
+func storedFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	var keys []string
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err == nil && strings.HasSuffix(name, ".parquet") {
+			key, _ := filepath.Rel(dir, name)
+			keys = append(keys, filepath.ToSlash(key))
+		}
+
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+func readMatchRows(t *testing.T, name string) []sports.FootballMatchParquetRow {
+	t.Helper()
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := buffer.NewBufferFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pr, err := reader.NewParquetReader(file, new(sports.FootballMatchParquetRow), 1)
+	if err != nil {
+		t.Fatalf("NewParquetReader() error = %v", err)
+	}
+	defer pr.ReadStop()
+
+	rows := make([]sports.FootballMatchParquetRow, pr.GetNumRows())
+	if err := pr.Read(&rows); err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	return rows
+}
+
+func TestArchiveSinkFlush(t *testing.T) {
+	store := &LocalArchiveStore{Dir: t.TempDir()}
+	as := newTestArchiveSink(t, store)
+
+	matches := []*sports.FootballMatch{newTestMatch("Premier League"), newTestMatch("Premier League"), newTestMatch("La Liga")}
+	for _, fm := range matches {
+		if err := as.Write(NewFootballMatchRecord(fm)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	ev := sports.NewFootballMatchEvent(matches[0], sports.FootballMatchEventKickOff, 0)
+	ev.OccurredAt = matches[0].UpdatedAt
+
+	if err := as.Write(NewFootballMatchEventRecord(ev)); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	if as.Pending() != 4 || as.Due() {
+		t.Fatalf("Pending(), Due() = %d, %t, want 4, false", as.Pending(), as.Due())
+	}
+
+	// nothing is visible in the store before the files are complete
+	if files := storedFiles(t, store.Dir); len(files) != 0 {
+		t.Fatalf("stored files before Flush = %v, want none", files)
+	}
+
+	if err := as.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	manifests := readManifests(t, store.Dir)
+	if len(manifests) != 1 {
+		t.Fatalf("%d manifests, want 1", len(manifests))
+	}
+
+	var listed []string
+	for _, file := range manifests[0].Files {
+		listed = append(listed, file.Key)
+	}
+
+	if files := storedFiles(t, store.Dir); !slices.Equal(listed, files) {
+		t.Errorf("manifest lists %v, want the stored files %v", listed, files)
+	}
+
+	wantPartitions := []string{
+		"archive/football-match-event/sport=football/competition=unknown/date=2024-05-01/",
+		"archive/football-match-new/sport=football/competition=La%20Liga/date=2024-05-01/",
+		"archive/football-match-new/sport=football/competition=Premier%20League/date=2024-05-01/",
+	}
+
+	for i, file := range manifests[0].Files {
+		if i >= len(wantPartitions) || !strings.HasPrefix(file.Key, wantPartitions[i]) {
+			t.Errorf("manifest file %d = %s, want it under %v", i, file.Key, wantPartitions)
+		}
+	}
+
+	premierLeague := manifests[0].Files[2]
+	if premierLeague.Rows != 2 || premierLeague.MaxVersion != matches[0].Version() {
+		t.Errorf("Premier League file = %+v, want 2 rows at version %d", premierLeague, matches[0].Version())
+	}
+
+	rows := readMatchRows(t, filepath.Join(store.Dir, filepath.FromSlash(premierLeague.Key)))
+
+	if len(rows) != 2 || rows[0].ID != matches[0].ID.String() || rows[1].ID != matches[1].ID.String() ||
+		rows[0].KickOff != matches[0].KickOff.UnixMilli() || rows[0].HomeTeamName != matches[0].HomeTeam.Name {
+		t.Errorf("Premier League rows = %+v, want matches %s and %s", rows, matches[0].ID, matches[1].ID)
+	}
+
+	if as.Pending() != 0 {
+		t.Errorf("Pending() = %d after Flush, want 0", as.Pending())
+	}
+
+	if entries, _ := os.ReadDir(as.StagingDir); len(entries) != 0 {
+		t.Errorf("%d files left in the staging directory, want none", len(entries))
+	}
+}
+
+func TestArchiveSinkFlushFailedPut(t *testing.T) {
+	store := &failingStore{LocalArchiveStore: LocalArchiveStore{Dir: t.TempDir()}, failKey: "La%20Liga"}
+	as := newTestArchiveSink(t, store)
+
+	premierLeague, laLiga := newTestMatch("Premier League"), newTestMatch("La Liga")
+
+	for _, fm := range []*sports.FootballMatch{premierLeague, laLiga} {
+		if err := as.Write(NewFootballMatchRecord(fm)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+
+	err := as.Flush(context.Background())
+
+	// only the records of the file that was not put are reported, so only their messages are redelivered
+	if ItemError(err, laLiga.ID.String()) == nil || ItemError(err, premierLeague.ID.String()) != nil {
+		t.Fatalf("Flush() error = %v, want only the La Liga match failed", err)
+	}
+
+	manifests := readManifests(t, store.Dir)
+	if len(manifests) != 1 || len(manifests[0].Files) != 1 || manifests[0].Files[0].Competition != "Premier League" {
+		t.Fatalf("manifests = %+v, want one listing only the Premier League file", manifests)
+	}
+
+	if files := storedFiles(t, store.Dir); !slices.Equal(files, []string{manifests[0].Files[0].Key}) {
+		t.Errorf("stored files = %v, want only the listed one", files)
+	}
+}
+
+func TestArchiveSinkFlushFailedManifest(t *testing.T) {
+	store := &failingStore{LocalArchiveStore: LocalArchiveStore{Dir: t.TempDir()}, failKey: "_manifests"}
+	as := newTestArchiveSink(t, store)
+
+	fm := newTestMatch("Serie A")
+	_ = as.Write(NewFootballMatchRecord(fm))
+
+	// a file that no manifest lists is never read downstream, so its records failed
+	if err := as.Flush(context.Background()); ItemError(err, fm.ID.String()) == nil {
+		t.Fatalf("Flush() error = %v, want the match failed", err)
+	}
+}
+
+func TestArchiveSinkDue(t *testing.T) {
+	as := NewArchiveSink(&LocalArchiveStore{Dir: t.TempDir()}, "", t.TempDir(), 1<<20, 0, RetryPolicy{})
+	defer as.Close()
+
+	if as.Due() {
+		t.Error("Due() = true without open files")
+	}
+
+	_ = as.Write(NewFootballMatchRecord(newTestMatch("Bundesliga")))
+
+	if !as.Due() {
+		t.Error("Due() = false once a file has been open for MaxFileAge")
+	}
+
+	as.MaxFileAge, as.MaxFileBytes = time.Hour, 1
+
+	if !as.Due() {
+		t.Error("Due() = false once a file has reached MaxFileBytes")
+	}
+
+	if err := as.Write(&Record{Topic: "other", Key: "a", Value: "not a match"}); !errors.Is(err, ErrUnsupportedRecord) {
+		t.Errorf("Write() of a string error = %v, want ErrUnsupportedRecord", err)
+	}
+}
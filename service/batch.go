@@ -0,0 +1,51 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// BatchError is returned by a sink batch flush that failed to write some of its items.
+// Items that are not in Failed were written.
+type BatchError struct {
+	Sink string
+	// Failed holds the error of every item that was not written, by item key.
+	Failed map[string]error
+}
+
+func (e *BatchError) Error() string {
+	keys := make([]string, 0, len(e.Failed))
+	for key := range e.Failed {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	if len(keys) == 1 {
+		return fmt.Sprintf("%s failed to write %s: %s", e.Sink, keys[0], e.Failed[keys[0]])
+	}
+
+	return fmt.Sprintf("%s failed to write %d items, first %s: %s", e.Sink, len(keys), keys[0], e.Failed[keys[0]])
+}
+
+// Unwrap returns the item errors, so that errors.Is and errors.As see the causes of the failure.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failed))
+	for _, err := range e.Failed {
+		errs = append(errs, err)
+	}
+
+	return errs
+}
+
+// ItemError returns the error of the item with the given key, which is nil when the item was written.
+// Any error other than a BatchError failed every item.
+func ItemError(err error, key string) error {
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		return batchErr.Failed[key]
+	}
+
+	return err
+}
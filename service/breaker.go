@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for writes to a sink whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets every write through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects every write until the open timeout has passed.
+	CircuitOpen
+	// CircuitHalfOpen lets writes through to probe whether the sink has recovered.
+	CircuitHalfOpen
+)
+
+func (cs CircuitState) String() string {
+	switch cs {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// CircuitBreaker stops writes to a sink after FailureThreshold consecutive failures,
+// and lets them through again once OpenTimeout has passed and a probe write succeeds.
+type CircuitBreaker struct {
+	Name             string
+	FailureThreshold int
+	OpenTimeout      time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+
+	// now is the breaker's clock, replaced in tests
+	now func() time.Time
+}
+
+// NewCircuitBreaker creates a closed circuit breaker for the named sink.
+func NewCircuitBreaker(name string, failureThreshold int, openTimeout time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{
+		Name:             name,
+		FailureThreshold: failureThreshold,
+		OpenTimeout:      openTimeout,
+		now:              time.Now,
+	}
+}
+
+// Allow reports whether a write may go through, moving an open breaker to half-open once its timeout has passed.
+func (cb *CircuitBreaker) Allow() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.OpenTimeout {
+		cb.state = CircuitHalfOpen
+	}
+
+	return cb.state != CircuitOpen
+}
+
+// Success records a successful write, closing the breaker.
+func (cb *CircuitBreaker) Success() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.state = CircuitClosed
+	cb.failures = 0
+}
+
+// Failure records a failed write, opening the breaker when the threshold is reached or a probe fails.
+func (cb *CircuitBreaker) Failure() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.failures++
+
+	if cb.state == CircuitHalfOpen || cb.failures >= cb.FailureThreshold {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.now()
+	}
+}
+
+// State returns the current state of the breaker, without moving it to half-open.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state
+}
+
+// ReadyToProbe reports whether the breaker is open and its timeout has passed.
+func (cb *CircuitBreaker) ReadyToProbe() bool {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	return cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.OpenTimeout
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// newTestBreaker returns a breaker that opens after three failures, on a clock advanced by the returned function.
+func newTestBreaker() (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2026, 10, 24, 15, 0, 0, 0, time.UTC)
+
+	cb := NewCircuitBreaker("test", 3, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	return cb, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestCircuitBreakerOpens(t *testing.T) {
+	cb, _ := newTestBreaker()
+
+	cb.Failure()
+	cb.Failure()
+
+	if !cb.Allow() || cb.State() != CircuitClosed {
+		t.Fatalf("breaker is %s below the failure threshold, want closed", cb.State())
+	}
+
+	// a success resets the count of consecutive failures
+	cb.Success()
+	cb.Failure()
+	cb.Failure()
+
+	if cb.State() != CircuitClosed {
+		t.Fatalf("breaker is %s after a success reset the failures, want closed", cb.State())
+	}
+
+	cb.Failure()
+
+	if cb.Allow() || cb.State() != CircuitOpen {
+		t.Errorf("breaker is %s at the failure threshold, want open", cb.State())
+	}
+}
+
+func TestCircuitBreakerProbe(t *testing.T) {
+	cb, advance := newTestBreaker()
+
+	for range 3 {
+		cb.Failure()
+	}
+
+	advance(59 * time.Second)
+
+	if cb.ReadyToProbe() || cb.Allow() {
+		t.Fatal("breaker lets writes through before its open timeout")
+	}
+
+	advance(time.Second)
+
+	if !cb.ReadyToProbe() || cb.State() != CircuitOpen {
+		t.Fatalf("breaker is %s and ready to probe %t at its open timeout, want open and ready",
+			cb.State(), cb.ReadyToProbe())
+	}
+
+	if !cb.Allow() || cb.State() != CircuitHalfOpen {
+		t.Fatalf("breaker is %s after its open timeout, want half-open", cb.State())
+	}
+
+	// a failed probe opens the breaker again for another full timeout
+	cb.Failure()
+
+	if cb.Allow() || cb.State() != CircuitOpen {
+		t.Fatalf("breaker is %s after a failed probe, want open", cb.State())
+	}
+
+	advance(30 * time.Second)
+
+	if cb.Allow() {
+		t.Fatal("breaker lets writes through before the timeout restarted by the failed probe")
+	}
+
+	advance(30 * time.Second)
+
+	if !cb.Allow() {
+		t.Fatal("breaker does not let a probe through after the restarted timeout")
+	}
+
+	cb.Success()
+
+	if cb.State() != CircuitClosed {
+		t.Fatalf("breaker is %s after a successful probe, want closed", cb.State())
+	}
+
+	cb.Failure()
+	cb.Failure()
+
+	if cb.State() != CircuitClosed {
+		t.Errorf("breaker is %s after two failures following a successful probe, want closed", cb.State())
+	}
+}
+
+func TestCircuitStateString(t *testing.T) {
+	for state, want := range map[CircuitState]string{CircuitClosed: "closed", CircuitOpen: "open", CircuitHalfOpen: "half-open"} {
+		if got := state.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", state, got, want)
+		}
+	}
+}
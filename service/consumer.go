@@ -2,137 +2,736 @@ package service
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/aws"
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
-	"github.com/elastic/go-elasticsearch/v8"
+	"golang.org/x/exp/maps"
 
+	"github.com/tuannkhoi/sport-data-feed/codec"
 	"github.com/tuannkhoi/sport-data-feed/config"
-	"github.com/tuannkhoi/sport-data-feed/sports"
 )
 
+// KafkaConsumer is the part of *kafka.Consumer that SportDataConsumer uses.
+type KafkaConsumer interface {
+	SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error
+	ReadMessage(timeout time.Duration) (*kafka.Message, error)
+	Seek(partition kafka.TopicPartition, ignoredTimeoutMs int) error
+	Assignment() ([]kafka.TopicPartition, error)
+	Pause(partitions []kafka.TopicPartition) error
+	Resume(partitions []kafka.TopicPartition) error
+	CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error)
+	Close() error
+}
+
 type SportDataConsumer struct {
-	Consumer            *kafka.Consumer
-	Log                 *slog.Logger
-	DynamoDBClient      *dynamodb.Client
-	ElasticsearchClient *elasticsearch.TypedClient
-}
-
-// NewSportDataConsumer creates a new SportDataConsumer instance.
-func NewSportDataConsumer(
-	cfg *config.Config,
-	logger *slog.Logger,
-	dynamoDBClient *dynamodb.Client,
-	elasticsearchClient *elasticsearch.TypedClient,
-) (*SportDataConsumer, error) {
-	consumer, err := kafka.NewConsumer(cfg.KafkaConfigMap)
+	Consumer           KafkaConsumer
+	Log                *slog.Logger
+	Codecs             map[string]codec.Codec
+	FailurePolicy      string
+	MaxAttempts        int
+	DeadLetterProducer *kafka.Producer
+	Retry              RetryPolicy
+	// QueueSize is the number of fetched messages queued for each partition worker before its partition is paused.
+	QueueSize int
+	// NewConsumer creates the consumer that replaces Consumer after a fatal error.
+	NewConsumer func() (KafkaConsumer, error)
+	// ShutdownTimeout bounds the time spent draining the sink batches and committing on shutdown.
+	ShutdownTimeout time.Duration
+	// Topics names the Kafka topics that the topics of the codecs and sinks are consumed from.
+	Topics *config.TopicConfig
+	// StartAt, when set, starts the partitions that the consumer group has no committed offset for
+	// at the first message produced at or after it, to replay a topic from then.
+	StartAt time.Time
+	// IdleTimeout, when set, shuts the consumer down once no message has arrived for this long.
+	IdleTimeout time.Duration
+
+	sinks      []*sinkHandle
+	topicSinks map[string][]*sinkHandle
+	workers    map[topicPartition]*partitionWorker
+	offsets    *offsetTracker
+	// settings holds the consumer settings that can change while running, see ApplyConfig.
+	// applied is the settings that the sinks were last reconfigured with, by the polling goroutine.
+	settings atomic.Pointer[config.ConsumerConfig]
+	applied  *config.ConsumerConfig
+	// writes is the context of sink writes, which is cancelled when the shutdown deadline passes.
+	writes       context.Context
+	cancelWrites context.CancelFunc
+
+	// mu serialises pausing and resuming partitions, which workers and the polling goroutine both do.
+	mu     sync.Mutex
+	paused bool
+}
+
+// messageState is what processing left a message in.
+type messageState int
+
+const (
+	// messageRetry means the message must be redelivered.
+	messageRetry messageState = iota
+	// messageDone means the message is finished with.
+	messageDone
+	// messageBuffered means the message waits in a sink batch until it is flushed.
+	messageBuffered
+)
+
+// unflushedMessage is a processed message whose offset waits for the sink batches holding its record to be flushed.
+type unflushedMessage struct {
+	msg *kafka.Message
+	// key is the key of the message's record in the sink batches, empty when it is not buffered.
+	key string
+	// waiting holds the sinks whose batch still holds the record.
+	waiting []*sinkHandle
+	// err is the first error that a sink reported for the record.
+	err error
+}
+
+// Failure policies for messages that a sink failed to write.
+const (
+	FailurePolicyBlock      = "block"
+	FailurePolicySkip       = "skip"
+	FailurePolicyDeadLetter = "dead-letter"
+)
+
+// NewSportDataConsumer creates a new SportDataConsumer instance, writing each topic to the sinks enabled for it.
+func NewSportDataConsumer(cfg *config.Config, logger *slog.Logger) (*SportDataConsumer, error) {
+	sinks, topicSinks, err := newTopicSinks(cfg, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	codecs, err := NewTopicCodecs(cfg, maps.Keys(topicSinks)...)
 	if err != nil {
+		closeSinks(sinks)
+
+		return nil, err
+	}
+
+	deadLetterProducer, err := kafka.NewProducer(cfg.KafkaProducerConfigMap)
+	if err != nil {
+		closeSinks(sinks)
+
+		return nil, errors.New("Failed to create dead-letter Producer: " + err.Error())
+	}
+
+	consumer, err := kafka.NewConsumer(cfg.KafkaConsumerConfigMap)
+	if err != nil {
+		closeSinks(sinks)
+		deadLetterProducer.Close()
+
 		return nil, errors.New("Failed to create Consumer: " + err.Error())
 	}
 
-	return &SportDataConsumer{
-		Consumer:            consumer,
-		Log:                 logger,
-		DynamoDBClient:      dynamoDBClient,
-		ElasticsearchClient: elasticsearchClient,
-	}, nil
+	writes, cancelWrites := context.WithCancel(context.Background())
+
+	sdc := &SportDataConsumer{
+		Consumer:           consumer,
+		Log:                logger,
+		Codecs:             codecs,
+		Topics:             cfg.Topics,
+		FailurePolicy:      cfg.ConsumerConfig.FailurePolicy,
+		MaxAttempts:        cfg.ConsumerConfig.MaxAttempts,
+		DeadLetterProducer: deadLetterProducer,
+		Retry:              newRetryPolicy(cfg.ConsumerConfig),
+		QueueSize:          cfg.ConsumerConfig.PartitionQueueSize,
+		NewConsumer: func() (KafkaConsumer, error) {
+			return kafka.NewConsumer(cfg.KafkaConsumerConfigMap)
+		},
+		ShutdownTimeout: cfg.ConsumerConfig.ShutdownTimeout,
+		sinks:           sinks,
+		topicSinks:      topicSinks,
+		workers:         make(map[topicPartition]*partitionWorker),
+		offsets:         newOffsetTracker(cfg.ConsumerConfig.CommitBatchSize, cfg.ConsumerConfig.CommitInterval),
+		applied:         cfg.ConsumerConfig,
+		writes:          writes,
+		cancelWrites:    cancelWrites,
+	}
+
+	sdc.settings.Store(cfg.ConsumerConfig)
+
+	return sdc, nil
+}
+
+// ApplyConfig switches the running consumer to the commit and batch thresholds of the consumer settings.
+// The sinks of each partition pick them up before their next batch.
+func (sdc *SportDataConsumer) ApplyConfig(consumerConfig *config.ConsumerConfig) {
+	sdc.settings.Store(consumerConfig)
+	sdc.offsets.SetThresholds(consumerConfig.CommitBatchSize, consumerConfig.CommitInterval)
 }
 
-func (sdc *SportDataConsumer) Consume() {
-	if err := sdc.Consumer.SubscribeTopics([]string{sports.TopicNewFootballMatch}, nil); err != nil {
-		log.Fatalf("Failed to subscribe to topic: %s\n", err)
+// reconfigureSinks applies changed settings to the sinks that the workers' sinks are forked from.
+func (sdc *SportDataConsumer) reconfigureSinks() {
+	consumerConfig := sdc.settings.Load()
+	if consumerConfig == sdc.applied {
+		return
 	}
 
-	sigCh := make(chan os.Signal, 1)
-	defer close(sigCh)
+	for _, handle := range sdc.sinks {
+		handle.sink.Reconfigure(consumerConfig)
+	}
 
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	sdc.applied = consumerConfig
+}
+
+// Run reads football matches and events from the topics that have sinks and writes them to the sinks of their topic,
+// committing the offset of each message only after all sinks have accepted it and its batch has been flushed,
+// so every record is delivered at least once.
+// Each assigned partition is processed in order by a worker of its own, so partitions are processed in parallel.
+// While a sink's circuit breaker is open, the assigned partitions are paused.
+// Run returns once the context is done or the consumer has been idle for IdleTimeout, and the consumer has shut down,
+// closing the consumer and its sinks.
+func (sdc *SportDataConsumer) Run(ctx context.Context) error {
+	if err := sdc.subscribe(); err != nil {
+		return errors.Join(err, sdc.shutdown())
+	}
+
+	lastMessage := time.Now()
 
-consumeLoop:
 	for {
 		select {
-		case <-sigCh:
-			sdc.Log.Info("Received signal to close the consumer. Closing...")
+		case <-ctx.Done():
+			sdc.Log.Info("Shutting down the consumer...")
+
+			return sdc.shutdown()
+		default:
+		}
+
+		sdc.reconfigureSinks()
+		sdc.resumeIfReady()
 
-			if err := sdc.Consumer.Close(); err != nil {
-				sdc.Log.Warn("Failed to close consumer: " + err.Error())
+		for _, w := range sdc.workers {
+			sdc.deliver(w)
+		}
+
+		msg, err := sdc.Consumer.ReadMessage(100 * time.Millisecond)
+		if err != nil {
+			if err := sdc.handleReadError(ctx, err); err != nil {
+				return errors.Join(err, sdc.shutdown())
 			}
 
-			break consumeLoop
-		default:
-			msg, err := sdc.Consumer.ReadMessage(1000 * time.Millisecond)
-			if err != nil {
-				if err.Error() == kafka.ErrTimedOut.String() {
-					continue
-				}
+			if sdc.IdleTimeout > 0 && time.Since(lastMessage) >= sdc.IdleTimeout {
+				sdc.Log.Info(fmt.Sprintf("No message for %s, shutting down the consumer...", sdc.IdleTimeout))
 
-				sdc.Log.Error("Error reading message: " + err.Error())
+				return sdc.shutdown()
 			}
 
-			fmt.Printf("Consumed event from topic %s: key = %-10s value = %s\n\n",
-				*msg.TopicPartition.Topic, string(msg.Key), "see below")
+			continue
+		}
+
+		lastMessage = time.Now()
 
-			switch *msg.TopicPartition.Topic {
-			case sports.TopicNewFootballMatch:
-				fm := new(sports.FootballMatch)
+		sdc.dispatch(msg)
+	}
+}
 
-				if err := json.Unmarshal(msg.Value, fm); err != nil {
-					sdc.Log.Error("Failed to unmarshal football match: " + err.Error())
+func (sdc *SportDataConsumer) subscribe() error {
+	var topics []string
 
-					continue
-				}
+	for topic := range sdc.topicSinks {
+		topics = append(topics, sdc.Topics.Name(topic))
+	}
+
+	slices.Sort(topics)
+
+	if err := sdc.Consumer.SubscribeTopics(topics, sdc.rebalance); err != nil {
+		return errors.New("Failed to subscribe to topics: " + err.Error())
+	}
+
+	return nil
+}
+
+// handleReadError deals with an error reading a message. Most errors are reported by the client
+// while it recovers by itself, but a fatal error leaves the client unusable, so the consumer is recreated.
+// An error is returned when the consumer could not be recreated before the context was done.
+func (sdc *SportDataConsumer) handleReadError(ctx context.Context, err error) error {
+	var kafkaErr kafka.Error
+	if !errors.As(err, &kafkaErr) {
+		sdc.Log.Error("Error reading message: " + err.Error())
+
+		return nil
+	}
+
+	switch {
+	case kafkaErr.Code() == kafka.ErrTimedOut:
+		return nil
+	case kafkaErr.IsFatal():
+		sdc.Log.Error("Fatal consumer error, recreating the consumer: " + kafkaErr.Error())
+
+		return sdc.recreate(ctx)
+	case kafkaErr.IsRetriable():
+		sdc.Log.Warn("Retriable consumer error: " + kafkaErr.Error())
+	default:
+		sdc.Log.Error("Error reading message: " + kafkaErr.Error())
+	}
+
+	return nil
+}
 
-				fmt.Println(msg)
+// recreate drains the workers, replaces the consumer with a new one and subscribes it again,
+// retrying with backoff until it succeeds or the context is done.
+func (sdc *SportDataConsumer) recreate(ctx context.Context) error {
+	sdc.stopWorkers(nil)
+	sdc.commit()
 
-				if err := sdc.HandleNewFootballMatch(fm); err != nil {
-					sdc.Log.Error("Failed to handle new football match: " + err.Error())
-				}
+	if err := sdc.Consumer.Close(); err != nil {
+		sdc.Log.Warn("Failed to close consumer: " + err.Error())
+	}
+
+	sdc.mu.Lock()
+	sdc.paused = false
+	sdc.mu.Unlock()
+
+	for attempt := 1; ; attempt++ {
+		consumer, err := sdc.NewConsumer()
+		if err == nil {
+			sdc.Consumer = consumer
+
+			if err = sdc.subscribe(); err == nil {
+				sdc.Log.Info("Recreated the consumer")
+
+				return nil
+			}
+
+			consumer.Close()
+		}
+
+		sdc.Log.Error(fmt.Sprintf("Failed to recreate consumer (attempt %d): %s", attempt, err))
+
+		select {
+		case <-ctx.Done():
+			return errors.New("Failed to recreate consumer: " + err.Error())
+		case <-time.After(sdc.Retry.Backoff(attempt)):
+		}
+	}
+}
+
+// shutdown drains the workers, commits the final offsets and closes the consumer, the dead-letter producer
+// and the sinks. Sink writes still in flight when ShutdownTimeout has passed are cancelled, leaving their messages
+// uncommitted to be redelivered.
+func (sdc *SportDataConsumer) shutdown() error {
+	deadline := time.Now().Add(sdc.ShutdownTimeout)
+
+	timer := time.AfterFunc(sdc.ShutdownTimeout, func() {
+		sdc.Log.Warn("Shutdown deadline passed, cancelling the sink writes in flight")
+
+		sdc.cancelWrites()
+	})
+	defer timer.Stop()
+
+	sdc.stopWorkers(nil)
+	sdc.commit()
+
+	var errs []error
+
+	if err := sdc.Consumer.Close(); err != nil {
+		errs = append(errs, errors.New("Failed to close consumer: "+err.Error()))
+	}
+
+	if n := sdc.DeadLetterProducer.Flush(int(max(time.Until(deadline), 0).Milliseconds())); n > 0 {
+		errs = append(errs, fmt.Errorf("%d dead-letter messages were not delivered", n))
+	}
+
+	sdc.DeadLetterProducer.Close()
+
+	if err := closeSinks(sdc.sinks); err != nil {
+		errs = append(errs, err)
+	}
+
+	sdc.cancelWrites()
+
+	return errors.Join(errs...)
+}
+
+// dispatch queues the message for the worker of its partition.
+func (sdc *SportDataConsumer) dispatch(msg *kafka.Message) {
+	w, ok := sdc.workers[topicPartition{*msg.TopicPartition.Topic, msg.TopicPartition.Partition}]
+	if !ok {
+		sdc.Log.Warn(fmt.Sprintf("Dropped message of unassigned partition %s", msg.TopicPartition))
+
+		return
+	}
+
+	w.backlog = append(w.backlog, msg)
+
+	sdc.deliver(w)
+}
+
+// deliver moves the backlog of a worker to its queue. A partition whose queue is full is paused
+// until its worker catches up, so that a slow partition does not hold up the others.
+func (sdc *SportDataConsumer) deliver(w *partitionWorker) {
+	for len(w.backlog) > 0 {
+		select {
+		case w.messages <- w.backlog[0]:
+			w.backlog[0] = nil
+			w.backlog = w.backlog[1:]
+		default:
+			if !w.throttled {
+				sdc.setPaused(w.topicPartition(), true)
+
+				w.throttled = true
 			}
+
+			return
+		}
+	}
+
+	if w.throttled {
+		sdc.mu.Lock()
+		defer sdc.mu.Unlock()
+
+		// a paused consumer resumes every partition once the sinks recover
+		if !sdc.paused {
+			sdc.setPausedLocked(w.topicPartition(), false)
+		}
+
+		w.throttled = false
+	}
+}
+
+// startWorkers starts a worker for each newly assigned partition.
+func (sdc *SportDataConsumer) startWorkers(partitions []kafka.TopicPartition) {
+	for _, tp := range partitions {
+		w := newPartitionWorker(sdc, tp)
+
+		sdc.workers[topicPartition{w.topic, w.partition}] = w
+
+		go w.run()
+	}
+}
+
+// stopWorkers stops the workers of the given partitions, or every worker when none are given, and waits for them
+// to flush their sink batches and commit their offsets.
+func (sdc *SportDataConsumer) stopWorkers(partitions []kafka.TopicPartition) {
+	var stopped []*partitionWorker
+
+	for key, w := range sdc.workers {
+		if partitions != nil && !containsPartition(partitions, key) {
+			continue
+		}
+
+		close(w.stop)
+		delete(sdc.workers, key)
+
+		stopped = append(stopped, w)
+	}
+
+	for _, w := range stopped {
+		<-w.stopped
+	}
+}
+
+func finished(done bool) messageState {
+	if done {
+		return messageDone
+	}
+
+	return messageRetry
+}
+
+// deadLetter sends the message to the dead-letter topic of its topic, reporting whether it was delivered.
+func (sdc *SportDataConsumer) deadLetter(msg *kafka.Message, cause error, attempts int) bool {
+	topic, _ := sdc.Topics.Logical(*msg.TopicPartition.Topic)
+
+	dlm := NewDeadLetterMessage(msg, sdc.Topics.Name(DeadLetterTopic(topic)), cause, attempts)
+
+	if _, err := PublishSync(sdc.writes, sdc.DeadLetterProducer, dlm); err != nil {
+		sdc.Log.Error("Failed to send message to dead-letter topic, retrying: " + err.Error())
+
+		return false
+	}
+
+	sdc.Log.Warn(fmt.Sprintf("Sent message at offset %s of %s to %s: %s",
+		msg.TopicPartition.Offset, *msg.TopicPartition.Topic, *dlm.TopicPartition.Topic, cause))
+
+	return true
+}
+
+// sinkDown reports whether the circuit breaker of any sink is open.
+func (sdc *SportDataConsumer) sinkDown() bool {
+	for _, handle := range sdc.sinks {
+		if handle.breaker.State() == CircuitOpen {
+			return true
 		}
 	}
+
+	return false
 }
 
-func (sdc *SportDataConsumer) HandleNewFootballMatch(fm *sports.FootballMatch) error {
-	fmt.Printf("FootballMatch ID: %s\n", fm.ID)
-	fmt.Printf("Home Team: %s\n", fm.HomeTeam.Name)
-	fmt.Printf("Away Team: %s\n", fm.AwayTeam.Name)
-	fmt.Printf("Stadium: %s\n", fm.Stadium)
-	fmt.Printf("Round: %d\n", fm.Round)
-	fmt.Printf("Competition: %s\n", fm.Competition)
-	fmt.Printf("Country: %s\n", fm.Country)
-	fmt.Printf("Kick Off: %s\n", fm.KickOff.String())
+// pause stops fetching from every assigned partition while a sink is down.
+func (sdc *SportDataConsumer) pause() {
+	sdc.mu.Lock()
+	defer sdc.mu.Unlock()
+
+	if sdc.paused {
+		return
+	}
+
+	partitions, err := sdc.Consumer.Assignment()
+	if err != nil {
+		sdc.Log.Error("Failed to get assigned partitions: " + err.Error())
+
+		return
+	}
+
+	if err := sdc.Consumer.Pause(partitions); err != nil {
+		sdc.Log.Error("Failed to pause partitions: " + err.Error())
+
+		return
+	}
+
+	sdc.paused = true
 
-	fmt.Println()
+	sdc.Log.Warn(fmt.Sprintf("Paused %d partitions until the sinks recover", len(partitions)))
+}
+
+// resumeIfReady resumes the paused partitions once every open circuit breaker is ready to probe its sink,
+// except for the partitions throttled until their worker catches up.
+func (sdc *SportDataConsumer) resumeIfReady() {
+	sdc.mu.Lock()
+	defer sdc.mu.Unlock()
 
-	// add it to a batch, regularly flush the batch to DynamoDB
-	if _, err := sdc.DynamoDBClient.PutItem(context.TODO(), &dynamodb.PutItemInput{
-		TableName: aws.String("FootballMatches"),
-		Item:      fm.ToDynamoDBItem(),
-	}); err != nil {
-		return errors.New("Failed to put item to DynamoDB: " + err.Error())
+	if !sdc.paused {
+		return
 	}
 
-	sdc.Log.Info(fmt.Sprintf("Successfully added new football match to DynamoDB: %s\n", fm.ID.String()))
+	for _, handle := range sdc.sinks {
+		if handle.breaker.State() == CircuitOpen && !handle.breaker.ReadyToProbe() {
+			return
+		}
+	}
 
-	rsp, err := sdc.ElasticsearchClient.
-		Index("football-matches").
-		Request(fm.ToElasticSearchDocument()).
-		Do(context.Background())
+	assigned, err := sdc.Consumer.Assignment()
 	if err != nil {
-		return errors.New("Failed to index football match to Elasticsearch: " + err.Error())
+		sdc.Log.Error("Failed to get assigned partitions: " + err.Error())
+
+		return
 	}
 
-	sdc.Log.Info(fmt.Sprintf("Successfully indexed new football match to Elasticsearch: %s\n", rsp.Result))
+	partitions := slices.DeleteFunc(assigned, func(tp kafka.TopicPartition) bool {
+		w, ok := sdc.workers[topicPartition{*tp.Topic, tp.Partition}]
+
+		return ok && w.throttled
+	})
+
+	if err := sdc.Consumer.Resume(partitions); err != nil {
+		sdc.Log.Error("Failed to resume partitions: " + err.Error())
+
+		return
+	}
+
+	sdc.paused = false
+
+	sdc.Log.Info(fmt.Sprintf("Resumed %d partitions to probe the sinks", len(partitions)))
+}
+
+// setPaused pauses or resumes a single partition.
+func (sdc *SportDataConsumer) setPaused(tp kafka.TopicPartition, paused bool) {
+	sdc.mu.Lock()
+	defer sdc.mu.Unlock()
+
+	sdc.setPausedLocked(tp, paused)
+}
+
+func (sdc *SportDataConsumer) setPausedLocked(tp kafka.TopicPartition, paused bool) {
+	var err error
+
+	if paused {
+		err = sdc.Consumer.Pause([]kafka.TopicPartition{tp})
+	} else {
+		err = sdc.Consumer.Resume([]kafka.TopicPartition{tp})
+	}
+
+	if err != nil {
+		sdc.Log.Error(fmt.Sprintf("Failed to pause or resume partition %s: %s", tp, err))
+	}
+}
+
+// commit commits the offsets of every message that all sinks have accepted.
+func (sdc *SportDataConsumer) commit(partitions ...kafka.TopicPartition) {
+	if err := sdc.offsets.Commit(sdc.Consumer, partitions...); err != nil {
+		sdc.Log.Warn("Failed to commit offsets: " + err.Error())
+	}
+}
+
+// commitIfDue commits the offsets once enough messages have been processed or enough time has passed.
+func (sdc *SportDataConsumer) commitIfDue() {
+	if sdc.offsets.Due() {
+		sdc.commit()
+	}
+}
+
+// rebalance starts a worker for each assigned partition, keeping the partitions paused while a sink is down,
+// and drains the workers of revoked partitions, committing their offsets before another consumer takes them over.
+func (sdc *SportDataConsumer) rebalance(consumer *kafka.Consumer, event kafka.Event) error {
+	switch e := event.(type) {
+	case kafka.RevokedPartitions:
+		sdc.stopWorkers(e.Partitions)
+		sdc.commit(e.Partitions...)
+	case kafka.AssignedPartitions:
+		partitions := e.Partitions
+
+		if !sdc.StartAt.IsZero() {
+			var err error
+			if partitions, err = sdc.startPositions(consumer, partitions); err != nil {
+				sdc.Log.Error("Failed to position partitions at " + sdc.StartAt.Format(time.RFC3339) + ": " + err.Error())
+
+				return err
+			}
+		}
+
+		sdc.startWorkers(partitions)
+
+		sdc.mu.Lock()
+		defer sdc.mu.Unlock()
+
+		if !sdc.paused && sdc.StartAt.IsZero() {
+			return nil
+		}
+
+		if err := consumer.Assign(partitions); err != nil {
+			return err
+		}
+
+		if !sdc.paused {
+			return nil
+		}
+
+		return consumer.Pause(partitions)
+	}
 
 	return nil
 }
+
+// startPositions positions the partitions without a committed offset at StartAt,
+// leaving the others to resume from their committed offset.
+func (sdc *SportDataConsumer) startPositions(consumer *kafka.Consumer, partitions []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	committed, err := consumer.Committed(partitions, 10*1000)
+	if err != nil {
+		return nil, errors.New("Failed to get committed offsets: " + err.Error())
+	}
+
+	var uncommitted []kafka.TopicPartition
+
+	for _, tp := range committed {
+		if tp.Offset < 0 {
+			uncommitted = append(uncommitted, tp)
+		}
+	}
+
+	if len(uncommitted) == 0 {
+		return committed, nil
+	}
+
+	started, err := StartOffsets(consumer, uncommitted, sdc.StartAt)
+	if err != nil {
+		return nil, err
+	}
+
+	positioned := slices.Clone(committed)
+
+	for i, tp := range positioned {
+		for _, start := range started {
+			if *start.Topic == *tp.Topic && start.Partition == tp.Partition {
+				positioned[i].Offset = start.Offset
+			}
+		}
+	}
+
+	return positioned, nil
+}
+
+// StartOffsets returns the partitions positioned at the first message produced at or after the given time,
+// or at their end when no message has been produced since.
+func StartOffsets(consumer *kafka.Consumer, partitions []kafka.TopicPartition, at time.Time) ([]kafka.TopicPartition, error) {
+	times := make([]kafka.TopicPartition, len(partitions))
+	for i, tp := range partitions {
+		times[i] = kafka.TopicPartition{Topic: tp.Topic, Partition: tp.Partition, Offset: kafka.Offset(at.UnixMilli())}
+	}
+
+	offsets, err := consumer.OffsetsForTimes(times, 10*1000)
+	if err != nil {
+		return nil, errors.New("Failed to look up offsets by time: " + err.Error())
+	}
+
+	return offsets, nil
+}
+
+// throughBreaker writes to a sink through its circuit breaker.
+// Only transient failures count against the breaker, as a rejected write means the sink is up.
+func (sdc *SportDataConsumer) throughBreaker(breaker *CircuitBreaker, write func(ctx context.Context) error) error {
+	if !breaker.Allow() {
+		return fmt.Errorf("%s %w", breaker.Name, ErrCircuitOpen)
+	}
+
+	err := write(sdc.writes)
+	if err != nil && IsTransient(err) {
+		breaker.Failure()
+
+		if breaker.State() == CircuitOpen {
+			sdc.Log.Error(fmt.Sprintf("%s circuit breaker opened: %s", breaker.Name, err))
+		}
+
+		return err
+	}
+
+	if breaker.State() == CircuitHalfOpen {
+		sdc.Log.Info(breaker.Name + " circuit breaker closed")
+	}
+
+	breaker.Success()
+
+	return err
+}
+
+// attemptCounter counts consecutive failed attempts at the message each partition is blocked on.
+type attemptCounter struct {
+	attempts map[topicPartition]attempt
+}
+
+type attempt struct {
+	offset kafka.Offset
+	count  int
+}
+
+func newAttemptCounter() *attemptCounter {
+	return &attemptCounter{attempts: make(map[topicPartition]attempt)}
+}
+
+// Add records a failed attempt at the message at tp and returns how many attempts it has had.
+func (ac *attemptCounter) Add(tp kafka.TopicPartition) int {
+	key := topicPartition{*tp.Topic, tp.Partition}
+
+	a := ac.attempts[key]
+	if a.offset != tp.Offset {
+		a = attempt{offset: tp.Offset}
+	}
+
+	a.count++
+	ac.attempts[key] = a
+
+	return a.count
+}
+
+// Count returns how many failed attempts the message at tp has had.
+func (ac *attemptCounter) Count(tp kafka.TopicPartition) int {
+	a := ac.attempts[topicPartition{*tp.Topic, tp.Partition}]
+	if a.offset != tp.Offset {
+		return 0
+	}
+
+	return a.count
+}
+
+// Forget clears the attempts of the message at tp once it has been dealt with.
+func (ac *attemptCounter) Forget(tp kafka.TopicPartition) {
+	key := topicPartition{*tp.Topic, tp.Partition}
+
+	if ac.attempts[key].offset == tp.Offset {
+		delete(ac.attempts, key)
+	}
+}
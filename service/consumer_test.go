@@ -0,0 +1,1009 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+	"github.com/elastic/go-elasticsearch/v8"
+
+	"github.com/tuannkhoi/sport-data-feed/codec"
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// fakeConsumer replays a fixed log of messages. Subscribing assigns every partition of the log, and the assigned
+// partitions that are not paused are read in turn, each in order. Seek moves the read position of a partition back.
+type fakeConsumer struct {
+	mu         sync.Mutex
+	partitions []topicPartition
+	logs       map[topicPartition][]*kafka.Message
+	positions  map[topicPartition]int
+	assigned   map[topicPartition]bool
+	pausedNow  map[topicPartition]bool
+	turn       int
+	// topics are the topics subscribed to, and rebalanceCb is called with the queued rebalance events
+	// before the next message is read
+	topics      []string
+	rebalanceCb kafka.RebalanceCb
+	events      []kafka.Event
+	// seekErrs is the number of seeks left to fail
+	seekErrs int
+	// readErr, when set, is returned by the next read instead of a message
+	readErr error
+	seeks   []kafka.TopicPartition
+	paused  []kafka.TopicPartition
+	resumed []kafka.TopicPartition
+	commits [][]kafka.TopicPartition
+	// onCommit, when set, is called with every committed batch of offsets
+	onCommit func(offsets []kafka.TopicPartition)
+	closed   bool
+}
+
+func newFakeConsumer(log ...*kafka.Message) *fakeConsumer {
+	fc := &fakeConsumer{
+		logs:      make(map[topicPartition][]*kafka.Message),
+		positions: make(map[topicPartition]int),
+		assigned:  make(map[topicPartition]bool),
+		pausedNow: make(map[topicPartition]bool),
+	}
+
+	for _, msg := range log {
+		key := topicPartition{*msg.TopicPartition.Topic, msg.TopicPartition.Partition}
+		if _, ok := fc.logs[key]; !ok {
+			fc.partitions = append(fc.partitions, key)
+		}
+
+		fc.logs[key] = append(fc.logs[key], msg)
+	}
+
+	return fc
+}
+
+func (fc *fakeConsumer) SubscribeTopics(topics []string, rebalanceCb kafka.RebalanceCb) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.topics = topics
+	fc.rebalanceCb = rebalanceCb
+	fc.events = append(fc.events, kafka.AssignedPartitions{Partitions: fc.topicPartitions(fc.partitions)})
+
+	return nil
+}
+
+// revoke queues the revocation of the given partitions of the log.
+func (fc *fakeConsumer) revoke(partitions ...int32) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	var revoked []topicPartition
+
+	for _, key := range fc.partitions {
+		if slices.Contains(partitions, key.partition) {
+			revoked = append(revoked, key)
+		}
+	}
+
+	fc.events = append(fc.events, kafka.RevokedPartitions{Partitions: fc.topicPartitions(revoked)})
+}
+
+// fail makes the next read return err.
+func (fc *fakeConsumer) fail(err error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.readErr = err
+}
+
+func (fc *fakeConsumer) topicPartitions(keys []topicPartition) []kafka.TopicPartition {
+	partitions := make([]kafka.TopicPartition, 0, len(keys))
+
+	for _, key := range keys {
+		topic := key.topic
+		partitions = append(partitions, kafka.TopicPartition{Topic: &topic, Partition: key.partition})
+	}
+
+	return partitions
+}
+
+func (fc *fakeConsumer) ReadMessage(timeout time.Duration) (*kafka.Message, error) {
+	fc.mu.Lock()
+
+	for len(fc.events) > 0 {
+		event := fc.events[0]
+		fc.events = fc.events[1:]
+
+		switch e := event.(type) {
+		case kafka.AssignedPartitions:
+			for _, tp := range e.Partitions {
+				fc.assigned[topicPartition{*tp.Topic, tp.Partition}] = true
+			}
+		case kafka.RevokedPartitions:
+			for _, tp := range e.Partitions {
+				delete(fc.assigned, topicPartition{*tp.Topic, tp.Partition})
+			}
+		}
+
+		// the callback calls back into the consumer
+		fc.mu.Unlock()
+
+		if err := fc.rebalanceCb(nil, event); err != nil {
+			return nil, err
+		}
+
+		fc.mu.Lock()
+	}
+
+	if err := fc.readErr; err != nil {
+		fc.readErr = nil
+		fc.mu.Unlock()
+
+		return nil, err
+	}
+
+	for i := range fc.partitions {
+		key := fc.partitions[(fc.turn+i)%len(fc.partitions)]
+		if !fc.assigned[key] || fc.pausedNow[key] || fc.positions[key] >= len(fc.logs[key]) {
+			continue
+		}
+
+		msg := fc.logs[key][fc.positions[key]]
+		fc.positions[key]++
+		fc.turn = (fc.turn + i + 1) % len(fc.partitions)
+		fc.mu.Unlock()
+
+		return msg, nil
+	}
+
+	fc.mu.Unlock()
+
+	time.Sleep(min(timeout, 10*time.Millisecond))
+
+	return nil, kafka.NewError(kafka.ErrTimedOut, kafka.ErrTimedOut.String(), false)
+}
+
+func (fc *fakeConsumer) Seek(tp kafka.TopicPartition, _ int) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.seeks = append(fc.seeks, tp)
+
+	if fc.seekErrs > 0 {
+		fc.seekErrs--
+
+		return kafka.NewError(kafka.ErrState, "Erroneous state", false)
+	}
+
+	key := topicPartition{*tp.Topic, tp.Partition}
+
+	for i, msg := range fc.logs[key] {
+		if msg.TopicPartition.Offset == tp.Offset {
+			fc.positions[key] = i
+		}
+	}
+
+	return nil
+}
+
+func (fc *fakeConsumer) Assignment() ([]kafka.TopicPartition, error) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	var assigned []topicPartition
+
+	for _, key := range fc.partitions {
+		if fc.assigned[key] {
+			assigned = append(assigned, key)
+		}
+	}
+
+	return fc.topicPartitions(assigned), nil
+}
+
+func (fc *fakeConsumer) Pause(partitions []kafka.TopicPartition) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.paused = append(fc.paused, partitions...)
+
+	for _, tp := range partitions {
+		fc.pausedNow[topicPartition{*tp.Topic, tp.Partition}] = true
+	}
+
+	return nil
+}
+
+func (fc *fakeConsumer) Resume(partitions []kafka.TopicPartition) error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.resumed = append(fc.resumed, partitions...)
+
+	for _, tp := range partitions {
+		delete(fc.pausedNow, topicPartition{*tp.Topic, tp.Partition})
+	}
+
+	return nil
+}
+
+func (fc *fakeConsumer) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	fc.mu.Lock()
+	fc.commits = append(fc.commits, offsets)
+	onCommit := fc.onCommit
+	fc.mu.Unlock()
+
+	if onCommit != nil {
+		onCommit(offsets)
+	}
+
+	return offsets, nil
+}
+
+func (fc *fakeConsumer) Close() error {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	fc.closed = true
+
+	return nil
+}
+
+// committedOffsets flattens the committed batches into "partition@offset" strings.
+func (fc *fakeConsumer) committedOffsets() []string {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+
+	var offsets []string
+
+	for _, batch := range fc.commits {
+		for _, tp := range batch {
+			offsets = append(offsets, partitionOffset(tp))
+		}
+	}
+
+	return offsets
+}
+
+func partitionOffset(tp kafka.TopicPartition) string {
+	return fmt.Sprintf("%s/%d@%d", *tp.Topic, tp.Partition, tp.Offset)
+}
+
+// newFootballMatchMessage encodes a new football match as JSON at the given partition and offset.
+func newFootballMatchMessage(t *testing.T, partition int32, offset kafka.Offset) *kafka.Message {
+	t.Helper()
+
+	c, err := codec.New(codec.JSON, nil)
+	if err != nil {
+		t.Fatalf("codec.New() error = %v", err)
+	}
+
+	fm := sports.NewFootballMatch()
+
+	value, err := c.Marshal(sports.TopicNewFootballMatch, fm)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	topic := sports.TopicNewFootballMatch
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset},
+		Key:            []byte(fm.ID.String()),
+		Value:          value,
+	}
+}
+
+// newFakeDynamoDB returns a DynamoDB client backed by handler.
+func newFakeDynamoDB(t *testing.T, handler http.HandlerFunc) *dynamodb.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return dynamodb.New(dynamodb.Options{
+		Region:           "us-east-1",
+		BaseEndpoint:     aws.String(server.URL),
+		Credentials:      aws.AnonymousCredentials{},
+		RetryMaxAttempts: 1,
+	})
+}
+
+// newFakeElasticsearch returns an Elasticsearch client backed by handler.
+func newFakeElasticsearch(t *testing.T, handler http.HandlerFunc) *elasticsearch.TypedClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the client refuses to talk to anything that does not identify as Elasticsearch
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		w.Header().Set("Content-Type", "application/json")
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := elasticsearch.NewTypedClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("NewTypedClient() error = %v", err)
+	}
+
+	return client
+}
+
+func acceptDynamoDBWrites(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	_, _ = io.WriteString(w, "{}")
+}
+
+func acceptElasticsearchWrites(w http.ResponseWriter, r *http.Request) {
+	writeBulkResponse(w, r, func(string, int64) int { return http.StatusCreated })
+}
+
+// runConsume runs the consumer until until returns true, then stops it and returns the error it stopped with.
+func runConsume(t *testing.T, sdc *SportDataConsumer, until func() bool) error {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- sdc.Run(ctx)
+	}()
+
+	deadline := time.After(10 * time.Second)
+
+	for !until() {
+		select {
+		case <-deadline:
+			t.Fatal("timed out waiting for the consumer")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	cancel()
+
+	return <-done
+}
+
+func newTestConsumer(t *testing.T, fc *fakeConsumer, failurePolicy string,
+	dynamoDB http.HandlerFunc, es http.HandlerFunc) *SportDataConsumer {
+	t.Helper()
+
+	// every message is flushed on its own, so that its offset can be committed straight away
+	return newConsumerWithSinks(t, fc, failurePolicy, 1,
+		&sinkHandle{
+			name: "dynamodb",
+			sink: NewDynamoDBBatchWriter(newFakeDynamoDB(t, dynamoDB), "FootballMatches", "id", "version", 1, time.Hour,
+				RetryPolicy{MaxAttempts: 1}),
+			breaker: NewCircuitBreaker("dynamodb", 5, time.Minute),
+		},
+		&sinkHandle{
+			name: "elasticsearch",
+			sink: NewElasticsearchBulkIndexer(newFakeElasticsearch(t, es), "football-matches", 1, 0, time.Hour,
+				RetryPolicy{MaxAttempts: 1}),
+			breaker: NewCircuitBreaker("elasticsearch", 5, time.Minute),
+		})
+}
+
+// newConsumerWithSinks creates a consumer of new football matches writing to the given sinks,
+// committing the offsets once commitBatch messages have been processed.
+func newConsumerWithSinks(t *testing.T, fc *fakeConsumer, failurePolicy string, commitBatch int,
+	sinks ...*sinkHandle) *SportDataConsumer {
+	t.Helper()
+
+	c, err := codec.New(codec.JSON, nil)
+	if err != nil {
+		t.Fatalf("codec.New() error = %v", err)
+	}
+
+	// nothing is dead-lettered in these tests, so the producer never needs to reach a broker
+	deadLetterProducer, err := kafka.NewProducer(&kafka.ConfigMap{"bootstrap.servers": "127.0.0.1:1"})
+	if err != nil {
+		t.Fatalf("NewProducer() error = %v", err)
+	}
+
+	// drain the connection errors, which would otherwise hold up Flush on shutdown
+	go func() {
+		for range deadLetterProducer.Events() {
+		}
+	}()
+
+	writes, cancelWrites := context.WithCancel(context.Background())
+	t.Cleanup(cancelWrites)
+
+	return &SportDataConsumer{
+		Consumer:           fc,
+		Log:                slog.New(slog.NewTextHandler(io.Discard, nil)),
+		Codecs:             map[string]codec.Codec{sports.TopicNewFootballMatch: c},
+		FailurePolicy:      failurePolicy,
+		MaxAttempts:        3,
+		DeadLetterProducer: deadLetterProducer,
+		Topics:             &config.TopicConfig{},
+		// a failed write is redelivered straight away
+		Retry:           RetryPolicy{MaxAttempts: 1},
+		QueueSize:       16,
+		ShutdownTimeout: 10 * time.Second,
+		NewConsumer: func() (KafkaConsumer, error) {
+			return nil, errors.New("no broker in tests")
+		},
+		sinks:        sinks,
+		topicSinks:   map[string][]*sinkHandle{sports.TopicNewFootballMatch: sinks},
+		workers:      make(map[topicPartition]*partitionWorker),
+		offsets:      newOffsetTracker(commitBatch, time.Hour),
+		writes:       writes,
+		cancelWrites: cancelWrites,
+	}
+}
+
+// TestConsumeCommitsAfterAllSinksAccept fails the first Elasticsearch write and checks that
+// the message's offset is committed only once it has been redelivered and every sink accepted it.
+func TestConsumeCommitsAfterAllSinksAccept(t *testing.T) {
+	var (
+		mu         sync.Mutex
+		esRequests int
+		esAccepted int
+	)
+
+	es := func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		esRequests++
+		if esRequests == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = io.WriteString(w, `{"error":{"type":"exception","reason":"boom"},"status":500}`)
+
+			return
+		}
+
+		esAccepted++
+		acceptElasticsearchWrites(w, r)
+	}
+
+	fc := newFakeConsumer(newFootballMatchMessage(t, 0, 0), newFootballMatchMessage(t, 0, 1))
+
+	var acceptedAtCommit []int
+
+	fc.onCommit = func([]kafka.TopicPartition) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		acceptedAtCommit = append(acceptedAtCommit, esAccepted)
+	}
+
+	sdc := newTestConsumer(t, fc, FailurePolicyBlock, acceptDynamoDBWrites, es)
+
+	runConsume(t, sdc, func() bool { return len(fc.committedOffsets()) == 2 })
+
+	want := []string{"football-match-new/0@1", "football-match-new/0@2"}
+	if got := fc.committedOffsets(); !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+
+	if len(fc.seeks) != 1 || fc.seeks[0].Offset != 0 {
+		t.Errorf("seeks = %v, want one rewind to offset 0", fc.seeks)
+	}
+
+	// each commit may only happen once Elasticsearch has accepted the message it covers
+	if len(acceptedAtCommit) < 2 || acceptedAtCommit[0] < 1 || acceptedAtCommit[1] < 2 {
+		t.Errorf("Elasticsearch had accepted %v messages at each commit, want at least [1 2]", acceptedAtCommit)
+	}
+
+	if !fc.closed {
+		t.Error("consumer was not closed")
+	}
+}
+
+func TestConsumeSkipPolicy(t *testing.T) {
+	failDynamoDB := func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"__type":"com.amazonaws.dynamodb.v20120810#ValidationException","message":"boom"}`)
+	}
+
+	fc := newFakeConsumer(newFootballMatchMessage(t, 0, 0))
+
+	sdc := newTestConsumer(t, fc, FailurePolicySkip, failDynamoDB, acceptElasticsearchWrites)
+
+	runConsume(t, sdc, func() bool { return len(fc.committedOffsets()) == 1 })
+
+	if got, want := fc.committedOffsets(), []string{"football-match-new/0@1"}; !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+
+	if len(fc.seeks) != 0 {
+		t.Errorf("seeks = %v, want none", fc.seeks)
+	}
+}
+
+// recording collects what the forks of a recordingSink wrote and flushed.
+type recording struct {
+	mu      sync.Mutex
+	written int
+	flushes [][]string
+	// failFlushes is the number of flushes left to fail
+	failFlushes int
+	// blockFlushes makes every flush wait until its context is done
+	blockFlushes bool
+	cancelled    int
+}
+
+func (r *recording) writes() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.written
+}
+
+// flushed returns the keys of every successful flush.
+func (r *recording) flushed() [][]string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.flushes)
+}
+
+// recordingSink is a sink that records the keys of the records it flushes, due once it holds batchSize records.
+type recordingSink struct {
+	batchSize int
+	pending   []string
+	rec       *recording
+}
+
+func (s *recordingSink) Write(record *Record) error {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+
+	s.rec.written++
+	s.pending = append(s.pending, record.Key)
+
+	return nil
+}
+
+func (s *recordingSink) Pending() int { return len(s.pending) }
+func (s *recordingSink) Due() bool    { return len(s.pending) >= s.batchSize }
+func (s *recordingSink) Discard()     { s.pending = nil }
+func (s *recordingSink) Close() error { return nil }
+
+func (s *recordingSink) Flush(ctx context.Context) error {
+	s.rec.mu.Lock()
+	defer s.rec.mu.Unlock()
+
+	if s.rec.blockFlushes {
+		s.rec.mu.Unlock()
+		<-ctx.Done()
+		s.rec.mu.Lock()
+
+		s.rec.cancelled++
+
+		return ctx.Err()
+	}
+
+	if s.rec.failFlushes > 0 {
+		s.rec.failFlushes--
+		s.pending = nil
+
+		return errors.New("boom")
+	}
+
+	s.rec.flushes = append(s.rec.flushes, s.pending)
+	s.pending = nil
+
+	return nil
+}
+
+func (s *recordingSink) Fork() Sink {
+	return &recordingSink{batchSize: s.batchSize, rec: s.rec}
+}
+
+// Reconfigure takes the batch size from the DynamoDB settings, standing in for the settings of a real sink.
+func (s *recordingSink) Reconfigure(consumerConfig *config.ConsumerConfig) {
+	s.batchSize = consumerConfig.DynamoDBBatchSize
+}
+
+func newRecordingSink(batchSize int) (*sinkHandle, *recording) {
+	rec := new(recording)
+
+	return &sinkHandle{
+		name:    "recording",
+		sink:    &recordingSink{batchSize: batchSize, rec: rec},
+		breaker: NewCircuitBreaker("recording", 5, time.Minute),
+	}, rec
+}
+
+// messageKeys returns the keys of the messages of the given partition, in order.
+func messageKeys(partition int32, log ...*kafka.Message) []string {
+	var keys []string
+
+	for _, msg := range log {
+		if msg.TopicPartition.Partition == partition {
+			keys = append(keys, string(msg.Key))
+		}
+	}
+
+	return keys
+}
+
+func TestConsumeKeepsPartitionOrder(t *testing.T) {
+	var log []*kafka.Message
+
+	for offset := range 5 {
+		log = append(log,
+			newFootballMatchMessage(t, 0, kafka.Offset(offset)),
+			newFootballMatchMessage(t, 1, kafka.Offset(offset)))
+	}
+
+	handle, rec := newRecordingSink(1)
+	fc := newFakeConsumer(log...)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+
+	runConsume(t, sdc, func() bool { return len(rec.flushed()) == len(log) })
+
+	flushed := make(map[string]int)
+	for i, keys := range rec.flushed() {
+		flushed[keys[0]] = i
+	}
+
+	for _, partition := range []int32{0, 1} {
+		keys := messageKeys(partition, log...)
+
+		for i := 1; i < len(keys); i++ {
+			if flushed[keys[i-1]] > flushed[keys[i]] {
+				t.Errorf("partition %d: message %d was flushed before message %d", partition, i, i-1)
+			}
+		}
+	}
+
+	want := []string{"football-match-new/0@5", "football-match-new/1@5"}
+	if got := fc.committedOffsets(); !slices.Contains(got, want[0]) || !slices.Contains(got, want[1]) {
+		t.Errorf("committed offsets = %v, want %v included", got, want)
+	}
+}
+
+func TestConsumeDrainsRevokedPartition(t *testing.T) {
+	log := []*kafka.Message{
+		newFootballMatchMessage(t, 0, 0),
+		newFootballMatchMessage(t, 0, 1),
+		newFootballMatchMessage(t, 1, 0),
+	}
+
+	// neither the batches nor the offsets are ever due, so only draining writes and commits them
+	handle, rec := newRecordingSink(100)
+	fc := newFakeConsumer(log...)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 100, handle)
+
+	var (
+		revoked         bool
+		commitsAtRevoke []string
+		flushedAtRevoke [][]string
+	)
+
+	runConsume(t, sdc, func() bool {
+		if !revoked {
+			if rec.writes() == len(log) {
+				fc.revoke(0)
+
+				revoked = true
+			}
+
+			return false
+		}
+
+		commitsAtRevoke, flushedAtRevoke = fc.committedOffsets(), rec.flushed()
+
+		return len(commitsAtRevoke) > 0
+	})
+
+	if want := []string{"football-match-new/0@2"}; !slices.Equal(commitsAtRevoke, want) {
+		t.Errorf("committed offsets on revoke = %v, want %v", commitsAtRevoke, want)
+	}
+
+	if want := messageKeys(0, log...); len(flushedAtRevoke) != 1 || !slices.Equal(flushedAtRevoke[0], want) {
+		t.Errorf("flushed on revoke = %v, want the batch of partition 0 %v", flushedAtRevoke, want)
+	}
+
+	// the partition still assigned is drained on shutdown
+	want := []string{"football-match-new/0@2", "football-match-new/1@1"}
+	if got := fc.committedOffsets(); !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+}
+
+func TestConsumeCommitsFlushedBatches(t *testing.T) {
+	log := []*kafka.Message{
+		newFootballMatchMessage(t, 0, 0),
+		newFootballMatchMessage(t, 0, 1),
+		newFootballMatchMessage(t, 0, 2),
+	}
+
+	handle, rec := newRecordingSink(2)
+	fc := newFakeConsumer(log...)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+
+	var commitsBeforeStop []string
+
+	runConsume(t, sdc, func() bool {
+		commitsBeforeStop = fc.committedOffsets()
+
+		return rec.writes() == len(log) && len(commitsBeforeStop) > 0
+	})
+
+	// the last message waits in a batch that is not due, so its offset is left uncommitted
+	if want := []string{"football-match-new/0@2"}; !slices.Equal(commitsBeforeStop, want) {
+		t.Errorf("committed offsets before shutdown = %v, want %v", commitsBeforeStop, want)
+	}
+
+	want := []string{"football-match-new/0@2", "football-match-new/0@3"}
+	if got := fc.committedOffsets(); !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+}
+
+// TestConsumeRetriesFailedSeek fails the flush of the first message and the seek back to it, and checks that
+// the messages after it are held back until the seek succeeds and the message is redelivered.
+func TestConsumeRetriesFailedSeek(t *testing.T) {
+	log := []*kafka.Message{
+		newFootballMatchMessage(t, 0, 0),
+		newFootballMatchMessage(t, 0, 1),
+		newFootballMatchMessage(t, 0, 2),
+	}
+
+	handle, rec := newRecordingSink(1)
+	rec.failFlushes = 1
+
+	fc := newFakeConsumer(log...)
+	fc.seekErrs = 1
+
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+
+	runConsume(t, sdc, func() bool { return len(fc.committedOffsets()) == len(log) })
+
+	var flushed []string
+	for _, keys := range rec.flushed() {
+		flushed = append(flushed, keys...)
+	}
+
+	if want := messageKeys(0, log...); !slices.Equal(flushed, want) {
+		t.Errorf("flushed = %v, want %v", flushed, want)
+	}
+
+	want := []string{"football-match-new/0@1", "football-match-new/0@2", "football-match-new/0@3"}
+	if got := fc.committedOffsets(); !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+
+	if len(fc.seeks) != 2 || fc.seeks[0].Offset != 0 || fc.seeks[1].Offset != 0 {
+		t.Errorf("seeks = %v, want two rewinds to offset 0", fc.seeks)
+	}
+}
+
+func TestHandleReadError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		recreate bool
+	}{
+		{"timeout", kafka.NewError(kafka.ErrTimedOut, kafka.ErrTimedOut.String(), false), false},
+		{"not fatal", kafka.NewError(kafka.ErrTransport, "Broker transport failure", false), false},
+		{"not a Kafka error", errors.New("boom"), false},
+		{"fatal", kafka.NewError(kafka.ErrFatal, "Fatal error", true), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fc := newFakeConsumer()
+			replacement := newFakeConsumer()
+
+			handle, _ := newRecordingSink(1)
+			sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+
+			var created int
+
+			sdc.NewConsumer = func() (KafkaConsumer, error) {
+				created++
+
+				return replacement, nil
+			}
+
+			if err := sdc.handleReadError(context.Background(), tt.err); err != nil {
+				t.Fatalf("handleReadError() error = %v", err)
+			}
+
+			if recreated := created > 0; recreated != tt.recreate {
+				t.Fatalf("recreated = %t, want %t", recreated, tt.recreate)
+			}
+
+			if !tt.recreate {
+				return
+			}
+
+			if !fc.closed || sdc.Consumer != replacement || replacement.rebalanceCb == nil {
+				t.Errorf("the consumer was not closed and replaced by a subscribed one")
+			}
+		})
+	}
+}
+
+// TestConsumeRecreatesConsumer fails the consumer fatally with messages still in a batch and checks that
+// they are flushed and committed before the consumer is replaced, which then carries on from the next message.
+func TestConsumeRecreatesConsumer(t *testing.T) {
+	log := []*kafka.Message{
+		newFootballMatchMessage(t, 0, 0),
+		newFootballMatchMessage(t, 0, 1),
+		newFootballMatchMessage(t, 0, 2),
+	}
+
+	handle, rec := newRecordingSink(100)
+	fc := newFakeConsumer(log[:2]...)
+	replacement := newFakeConsumer(log[2:]...)
+
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 100, handle)
+
+	var created int
+
+	sdc.NewConsumer = func() (KafkaConsumer, error) {
+		created++
+
+		// the first attempt fails, to be retried
+		if created == 1 {
+			return nil, errors.New("boom")
+		}
+
+		return replacement, nil
+	}
+
+	failed := false
+
+	if err := runConsume(t, sdc, func() bool {
+		if !failed && rec.writes() == 2 {
+			fc.fail(kafka.NewError(kafka.ErrFatal, "Fatal error", true))
+
+			failed = true
+		}
+
+		return rec.writes() == len(log)
+	}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if created != 2 {
+		t.Errorf("consumer created %d times, want 2", created)
+	}
+
+	if got, want := fc.committedOffsets(), []string{"football-match-new/0@2"}; !slices.Equal(got, want) || !fc.closed {
+		t.Errorf("failed consumer committed %v and closed = %t, want %v and closed", got, fc.closed, want)
+	}
+
+	if got, want := replacement.committedOffsets(), []string{"football-match-new/0@3"}; !slices.Equal(got, want) {
+		t.Errorf("new consumer committed %v, want %v", got, want)
+	}
+}
+
+func TestRecreateStopsWhenContextDone(t *testing.T) {
+	fc := newFakeConsumer()
+
+	handle, _ := newRecordingSink(1)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := sdc.recreate(ctx); err == nil {
+		t.Fatal("recreate() error = nil, want the consumer creation error")
+	}
+
+	if !fc.closed {
+		t.Error("the failed consumer was not closed")
+	}
+}
+
+// TestConsumeCancelsWritesOnShutdownTimeout blocks the sink and checks that shutdown cancels the write
+// once the deadline passes, leaving the message uncommitted.
+func TestConsumeCancelsWritesOnShutdownTimeout(t *testing.T) {
+	handle, rec := newRecordingSink(100)
+	rec.blockFlushes = true
+
+	fc := newFakeConsumer(newFootballMatchMessage(t, 0, 0))
+
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+	sdc.ShutdownTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+
+	_ = runConsume(t, sdc, func() bool {
+		start = time.Now()
+
+		return rec.writes() == 1
+	})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("shutdown took %s, want it bounded by the shutdown timeout", elapsed)
+	}
+
+	rec.mu.Lock()
+	cancelled := rec.cancelled
+	rec.mu.Unlock()
+
+	if cancelled != 1 {
+		t.Errorf("%d flushes cancelled, want 1", cancelled)
+	}
+
+	if got := fc.committedOffsets(); len(got) != 0 {
+		t.Errorf("committed offsets = %v, want none", got)
+	}
+
+	if !fc.closed {
+		t.Error("consumer was not closed")
+	}
+}
+
+// TestApplyConfig lowers the batch size and the commit threshold while a batch is buffered, and checks that the running
+// worker picks them up, flushing and committing the batch.
+func TestApplyConfig(t *testing.T) {
+	log := []*kafka.Message{
+		newFootballMatchMessage(t, 0, 0),
+		newFootballMatchMessage(t, 0, 1),
+	}
+
+	handle, rec := newRecordingSink(100)
+	fc := newFakeConsumer(log...)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 100, handle)
+
+	applied := false
+
+	_ = runConsume(t, sdc, func() bool {
+		if !applied && rec.writes() == len(log) {
+			sdc.ApplyConfig(&config.ConsumerConfig{DynamoDBBatchSize: 1, CommitBatchSize: 1, CommitInterval: time.Hour})
+
+			applied = true
+		}
+
+		return len(fc.committedOffsets()) > 0
+	})
+
+	if got := rec.flushed(); len(got) != 1 || !slices.Equal(got[0], messageKeys(0, log...)) {
+		t.Errorf("flushed = %v, want the buffered batch flushed once", got)
+	}
+
+	if got, want := fc.committedOffsets(), []string{"football-match-new/0@2"}; !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+}
+
+// TestConsumeTopicNames consumes a topic under the Kafka name of its environment and checks that its messages
+// still reach the sinks of the topic.
+func TestConsumeTopicNames(t *testing.T) {
+	msg := newFootballMatchMessage(t, 0, 0)
+	topic := "staging." + *msg.TopicPartition.Topic
+	msg.TopicPartition.Topic = &topic
+
+	handle, rec := newRecordingSink(1)
+	fc := newFakeConsumer(msg)
+	sdc := newConsumerWithSinks(t, fc, FailurePolicyBlock, 1, handle)
+	sdc.Topics = &config.TopicConfig{Prefix: "staging."}
+
+	_ = runConsume(t, sdc, func() bool { return len(fc.committedOffsets()) == 1 })
+
+	if !slices.Equal(fc.topics, []string{topic}) {
+		t.Errorf("subscribed to %v, want [%s]", fc.topics, topic)
+	}
+
+	if got := rec.flushed(); len(got) != 1 || !slices.Equal(got[0], []string{string(msg.Key)}) {
+		t.Errorf("flushed = %v, want the message's record", got)
+	}
+
+	if got, want := fc.committedOffsets(), []string{topic + "/0@1"}; !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Headers added to every message sent to a dead-letter topic.
+const (
+	HeaderDeadLetterTopic     = "dlq.original.topic"
+	HeaderDeadLetterPartition = "dlq.original.partition"
+	HeaderDeadLetterOffset    = "dlq.original.offset"
+	HeaderDeadLetterError     = "dlq.error"
+	HeaderDeadLetterAttempts  = "dlq.attempts"
+	HeaderDeadLetterTimestamp = "dlq.timestamp"
+
+	deadLetterHeaderPrefix = "dlq."
+)
+
+// DeadLetterTopic returns the dead-letter topic of the given topic.
+func DeadLetterTopic(topic string) string {
+	return topic + "-dlq"
+}
+
+// NewDeadLetterMessage wraps a message that could not be processed for the given dead-letter topic,
+// keeping its key, value and headers and recording where it came from and why it failed.
+func NewDeadLetterMessage(msg *kafka.Message, topic string, cause error, attempts int) *kafka.Message {
+	headers := append(withoutDeadLetterHeaders(msg.Headers),
+		kafka.Header{Key: HeaderDeadLetterTopic, Value: []byte(*msg.TopicPartition.Topic)},
+		kafka.Header{Key: HeaderDeadLetterPartition, Value: []byte(strconv.Itoa(int(msg.TopicPartition.Partition)))},
+		kafka.Header{Key: HeaderDeadLetterOffset, Value: []byte(strconv.FormatInt(int64(msg.TopicPartition.Offset), 10))},
+		kafka.Header{Key: HeaderDeadLetterError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDeadLetterAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDeadLetterTimestamp, Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))},
+	)
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        headers,
+	}
+}
+
+// NewRedriveMessage rebuilds the original message from a dead-lettered one, addressed to its original topic.
+func NewRedriveMessage(msg *kafka.Message) (*kafka.Message, error) {
+	topic := DeadLetterHeader(msg, HeaderDeadLetterTopic)
+	if topic == "" {
+		return nil, errors.New("message has no " + HeaderDeadLetterTopic + " header")
+	}
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
+		Key:            msg.Key,
+		Value:          msg.Value,
+		Headers:        withoutDeadLetterHeaders(msg.Headers),
+	}, nil
+}
+
+// DeadLetterHeader returns the value of the named header of a dead-lettered message.
+func DeadLetterHeader(msg *kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+
+	return ""
+}
+
+func withoutDeadLetterHeaders(headers []kafka.Header) []kafka.Header {
+	var kept []kafka.Header
+
+	for _, header := range headers {
+		if !strings.HasPrefix(header.Key, deadLetterHeaderPrefix) {
+			kept = append(kept, header)
+		}
+	}
+
+	return kept
+}
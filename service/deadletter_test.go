@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+func headerKeys(headers []kafka.Header) []string {
+	keys := make([]string, len(headers))
+	for i, header := range headers {
+		keys[i] = header.Key
+	}
+
+	return keys
+}
+
+func TestNewDeadLetterMessage(t *testing.T) {
+	topic := "football-match-new"
+
+	tests := []struct {
+		name     string
+		headers  []kafka.Header
+		wantKeys []string
+	}{
+		{
+			name: "no headers",
+			wantKeys: []string{HeaderDeadLetterTopic, HeaderDeadLetterPartition, HeaderDeadLetterOffset,
+				HeaderDeadLetterError, HeaderDeadLetterAttempts, HeaderDeadLetterTimestamp},
+		},
+		{
+			name:    "application headers kept",
+			headers: []kafka.Header{{Key: "trace-id", Value: []byte("abc")}},
+			wantKeys: []string{"trace-id", HeaderDeadLetterTopic, HeaderDeadLetterPartition, HeaderDeadLetterOffset,
+				HeaderDeadLetterError, HeaderDeadLetterAttempts, HeaderDeadLetterTimestamp},
+		},
+		{
+			// a redriven message that failed again carries the headers of its first trip
+			name: "earlier dead-letter headers replaced",
+			headers: []kafka.Header{
+				{Key: HeaderDeadLetterError, Value: []byte("old failure")},
+				{Key: "trace-id", Value: []byte("abc")},
+				{Key: HeaderDeadLetterAttempts, Value: []byte("9")},
+			},
+			wantKeys: []string{"trace-id", HeaderDeadLetterTopic, HeaderDeadLetterPartition, HeaderDeadLetterOffset,
+				HeaderDeadLetterError, HeaderDeadLetterAttempts, HeaderDeadLetterTimestamp},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: 3, Offset: 42},
+				Key:            []byte("key"),
+				Value:          []byte("value"),
+				Headers:        tt.headers,
+			}
+
+			before := time.Now().UTC().Truncate(time.Second)
+
+			dlm := NewDeadLetterMessage(msg, DeadLetterTopic(topic), errors.New("boom"), 5)
+
+			if *dlm.TopicPartition.Topic != "football-match-new-dlq" || dlm.TopicPartition.Partition != kafka.PartitionAny {
+				t.Errorf("dead-letter message is addressed to %v, want football-match-new-dlq on any partition", dlm.TopicPartition)
+			}
+
+			if string(dlm.Key) != "key" || string(dlm.Value) != "value" {
+				t.Errorf("dead-letter message key, value = %q, %q, want the original", dlm.Key, dlm.Value)
+			}
+
+			if got := headerKeys(dlm.Headers); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("headers = %v, want %v", got, tt.wantKeys)
+			}
+
+			for key, want := range map[string]string{
+				HeaderDeadLetterTopic:     topic,
+				HeaderDeadLetterPartition: "3",
+				HeaderDeadLetterOffset:    "42",
+				HeaderDeadLetterError:     "boom",
+				HeaderDeadLetterAttempts:  "5",
+			} {
+				if got := DeadLetterHeader(dlm, key); got != want {
+					t.Errorf("header %s = %q, want %q", key, got, want)
+				}
+			}
+
+			timestamp, err := time.Parse(time.RFC3339Nano, DeadLetterHeader(dlm, HeaderDeadLetterTimestamp))
+			if err != nil || timestamp.Before(before) {
+				t.Errorf("header %s = %s, %v, want the current time", HeaderDeadLetterTimestamp, timestamp, err)
+			}
+		})
+	}
+}
+
+func TestNewRedriveMessage(t *testing.T) {
+	dlqTopic := "football-match-new-dlq"
+
+	tests := []struct {
+		name      string
+		headers   []kafka.Header
+		wantTopic string
+		wantKeys  []string
+		wantErr   bool
+	}{
+		{
+			name: "dead-letter headers stripped",
+			headers: []kafka.Header{
+				{Key: HeaderDeadLetterTopic, Value: []byte("football-match-new")},
+				{Key: "trace-id", Value: []byte("abc")},
+				{Key: HeaderDeadLetterError, Value: []byte("boom")},
+				{Key: HeaderDeadLetterAttempts, Value: []byte("3")},
+			},
+			wantTopic: "football-match-new",
+			wantKeys:  []string{"trace-id"},
+		},
+		{
+			name:      "only dead-letter headers",
+			headers:   []kafka.Header{{Key: HeaderDeadLetterTopic, Value: []byte("football-match-event")}},
+			wantTopic: "football-match-event",
+			wantKeys:  []string{},
+		},
+		{
+			name:    "no original topic",
+			headers: []kafka.Header{{Key: "trace-id", Value: []byte("abc")}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := &kafka.Message{
+				TopicPartition: kafka.TopicPartition{Topic: &dlqTopic, Partition: 0, Offset: 7},
+				Key:            []byte("key"),
+				Value:          []byte("value"),
+				Headers:        tt.headers,
+			}
+
+			original, err := NewRedriveMessage(msg)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("NewRedriveMessage() = %v, want an error", original)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("NewRedriveMessage() error = %v", err)
+			}
+
+			if *original.TopicPartition.Topic != tt.wantTopic || original.TopicPartition.Partition != kafka.PartitionAny {
+				t.Errorf("redriven message is addressed to %v, want %s on any partition", original.TopicPartition, tt.wantTopic)
+			}
+
+			if string(original.Key) != "key" || string(original.Value) != "value" {
+				t.Errorf("redriven message key, value = %q, %q, want the original", original.Key, original.Value)
+			}
+
+			if got := headerKeys(original.Headers); !slices.Equal(got, tt.wantKeys) {
+				t.Errorf("headers = %v, want %v", got, tt.wantKeys)
+			}
+		})
+	}
+}
@@ -0,0 +1,305 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+func init() {
+	RegisterSink("dynamodb", newDynamoDBSink, sports.TopicNewFootballMatch)
+}
+
+// maxTransactItems is the most items DynamoDB accepts in a single TransactWriteItems request.
+const maxTransactItems = 100
+
+// ErrTransactionContended is returned when DynamoDB keeps cancelling a transaction because
+// its items are throttled or being written by another transaction.
+var ErrTransactionContended = errors.New("DynamoDB cancelled the transaction due to contention")
+
+// DynamoDBClient is the part of *dynamodb.Client that DynamoDBBatchWriter uses.
+type DynamoDBClient interface {
+	TransactWriteItems(ctx context.Context, params *dynamodb.TransactWriteItemsInput,
+		optFns ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error)
+}
+
+// DynamoDBBatchWriter buffers items for a table and writes them with TransactWriteItems,
+// once BatchSize items are buffered or FlushInterval has passed since the oldest one.
+//
+// When VersionAttribute is set, an item is only written if its version is newer than the stored one,
+// so replays and out-of-order deliveries converge on the latest state. BatchWriteItem does not support
+// conditions, hence the transactions, which consume twice the write capacity of plain writes.
+type DynamoDBBatchWriter struct {
+	Client           DynamoDBClient
+	Table            string
+	KeyAttribute     string
+	VersionAttribute string
+	BatchSize        int
+	FlushInterval    time.Duration
+	Retry            RetryPolicy
+
+	items  []map[string]types.AttributeValue
+	keys   map[string]int
+	oldest time.Time
+}
+
+// newDynamoDBSink writes football matches to the configured DynamoDB table, keyed by match ID and versioned.
+func newDynamoDBSink(cfg *config.Config, _ *slog.Logger) (Sink, error) {
+	return NewDynamoDBBatchWriter(
+		newDynamoDBClient(cfg),
+		cfg.ConsumerConfig.DynamoDBTable,
+		"id",
+		"version",
+		cfg.ConsumerConfig.DynamoDBBatchSize,
+		cfg.ConsumerConfig.DynamoDBFlushInterval,
+		newRetryPolicy(cfg.ConsumerConfig),
+	), nil
+}
+
+// newDynamoDBClient creates a DynamoDB client for AWS, or for the configured endpoint such as DynamoDB Local.
+func newDynamoDBClient(cfg *config.Config) *dynamodb.Client {
+	return dynamodb.NewFromConfig(*cfg.AWSConfig, func(o *dynamodb.Options) {
+		if endpoint := cfg.ConsumerConfig.DynamoDBEndpoint; endpoint != "" {
+			o.BaseEndpoint = &endpoint
+		}
+	})
+}
+
+// NewDynamoDBBatchWriter creates a batch writer for the table, whose items are keyed by keyAttribute
+// and versioned by versionAttribute, which may be empty to write items unconditionally.
+func NewDynamoDBBatchWriter(
+	client DynamoDBClient,
+	table string,
+	keyAttribute string,
+	versionAttribute string,
+	batchSize int,
+	flushInterval time.Duration,
+	retry RetryPolicy,
+) *DynamoDBBatchWriter {
+	if batchSize <= 0 || batchSize > maxTransactItems {
+		batchSize = maxTransactItems
+	}
+
+	return &DynamoDBBatchWriter{
+		Client:           client,
+		Table:            table,
+		KeyAttribute:     keyAttribute,
+		VersionAttribute: versionAttribute,
+		BatchSize:        batchSize,
+		FlushInterval:    flushInterval,
+		Retry:            retry,
+		keys:             make(map[string]int),
+	}
+}
+
+// Add buffers an item. An item with the same key as a buffered one replaces it unless it is older,
+// as DynamoDB rejects transactions that write the same key twice.
+func (w *DynamoDBBatchWriter) Add(item map[string]types.AttributeValue) {
+	key := w.key(item)
+
+	if i, ok := w.keys[key]; ok {
+		if w.version(item) >= w.version(w.items[i]) {
+			w.items[i] = item
+		}
+
+		return
+	}
+
+	if len(w.items) == 0 {
+		w.oldest = time.Now()
+	}
+
+	w.keys[key] = len(w.items)
+	w.items = append(w.items, item)
+}
+
+// Write buffers the DynamoDB item of a football match record.
+func (w *DynamoDBBatchWriter) Write(record *Record) error {
+	fm, ok := record.Value.(*sports.FootballMatch)
+	if !ok {
+		return fmt.Errorf("%w for DynamoDB: %T", ErrUnsupportedRecord, record.Value)
+	}
+
+	w.Add(fm.ToDynamoDBItem())
+
+	return nil
+}
+
+// Pending returns the number of buffered items.
+func (w *DynamoDBBatchWriter) Pending() int {
+	return len(w.items)
+}
+
+// Due reports whether the batch is full or its oldest item has waited FlushInterval.
+func (w *DynamoDBBatchWriter) Due() bool {
+	return len(w.items) >= w.BatchSize || (len(w.items) > 0 && time.Since(w.oldest) >= w.FlushInterval)
+}
+
+// Flush writes every buffered item in transactions of up to 100 items, retrying transient errors with backoff.
+// Items whose stored version is the same or newer are dropped, as there is nothing left to write.
+// When some items could not be written, Flush returns a *BatchError naming them. The buffer is empty afterwards.
+func (w *DynamoDBBatchWriter) Flush(ctx context.Context) error {
+	failed := make(map[string]error)
+
+	for items := w.items; len(items) > 0; {
+		n := min(len(items), maxTransactItems)
+
+		w.write(ctx, items[:n], failed)
+
+		items = items[n:]
+	}
+
+	w.Discard()
+
+	if len(failed) > 0 {
+		return &BatchError{Sink: "DynamoDB", Failed: failed}
+	}
+
+	return nil
+}
+
+// write writes the items in a single transaction, recording the error of every item that was not written in failed.
+// A cancelled transaction is resent without the items that caused it, which are either stale or failed.
+func (w *DynamoDBBatchWriter) write(ctx context.Context, items []map[string]types.AttributeValue, failed map[string]error) {
+	for attempt := 1; len(items) > 0; {
+		_, err := w.Client.TransactWriteItems(ctx, w.transaction(items))
+		if err == nil {
+			return
+		}
+
+		contended := false
+
+		var canceled *types.TransactionCanceledException
+		if errors.As(err, &canceled) && len(canceled.CancellationReasons) == len(items) {
+			var retry []map[string]types.AttributeValue
+
+			for i, reason := range canceled.CancellationReasons {
+				switch code := aws.ToString(reason.Code); code {
+				case "None":
+					retry = append(retry, items[i])
+				case "ConditionalCheckFailed":
+					// the same or a newer version is already stored, so there is nothing left to write
+				case "TransactionConflict", "ThrottlingError", "ProvisionedThroughputExceeded":
+					contended = true
+					retry = append(retry, items[i])
+				default:
+					failed[w.key(items[i])] = fmt.Errorf("%s: %s", code, aws.ToString(reason.Message))
+				}
+			}
+
+			progress := len(retry) < len(items)
+			items = retry
+
+			if !contended && progress {
+				continue
+			}
+
+			if contended {
+				err = fmt.Errorf("%w: %w", ErrTransactionContended, err)
+			}
+		}
+
+		if !IsTransient(err) || attempt >= w.Retry.MaxAttempts {
+			w.fail(items, err, failed)
+
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			w.fail(items, errors.Join(err, ctx.Err()), failed)
+
+			return
+		case <-time.After(w.Retry.Backoff(attempt)):
+		}
+
+		attempt++
+	}
+}
+
+// transaction builds the request that puts every item, on the condition that it is newer than the stored one
+// when VersionAttribute is set.
+func (w *DynamoDBBatchWriter) transaction(items []map[string]types.AttributeValue) *dynamodb.TransactWriteItemsInput {
+	transactItems := make([]types.TransactWriteItem, len(items))
+
+	for i, item := range items {
+		put := &types.Put{TableName: aws.String(w.Table), Item: item}
+
+		if w.VersionAttribute != "" {
+			put.ConditionExpression = aws.String("attribute_not_exists(#key) OR #version < :version")
+			put.ExpressionAttributeNames = map[string]string{
+				"#key":     w.KeyAttribute,
+				"#version": w.VersionAttribute,
+			}
+			put.ExpressionAttributeValues = map[string]types.AttributeValue{
+				":version": item[w.VersionAttribute],
+			}
+		}
+
+		transactItems[i] = types.TransactWriteItem{Put: put}
+	}
+
+	return &dynamodb.TransactWriteItemsInput{TransactItems: transactItems}
+}
+
+// fail records err as the error of every item.
+func (w *DynamoDBBatchWriter) fail(items []map[string]types.AttributeValue, err error, failed map[string]error) {
+	for _, item := range items {
+		failed[w.key(item)] = err
+	}
+}
+
+// Fork returns a batch writer for the same table.
+func (w *DynamoDBBatchWriter) Fork() Sink {
+	return NewDynamoDBBatchWriter(w.Client, w.Table, w.KeyAttribute, w.VersionAttribute, w.BatchSize, w.FlushInterval, w.Retry)
+}
+
+// Reconfigure applies the batch thresholds of the consumer settings.
+func (w *DynamoDBBatchWriter) Reconfigure(consumerConfig *config.ConsumerConfig) {
+	w.BatchSize = min(max(consumerConfig.DynamoDBBatchSize, 1), maxTransactItems)
+	w.FlushInterval = consumerConfig.DynamoDBFlushInterval
+}
+
+// Close drops every buffered item.
+func (w *DynamoDBBatchWriter) Close() error {
+	w.Discard()
+
+	return nil
+}
+
+// Discard drops every buffered item.
+func (w *DynamoDBBatchWriter) Discard() {
+	w.items = nil
+	w.keys = make(map[string]int)
+}
+
+func (w *DynamoDBBatchWriter) key(item map[string]types.AttributeValue) string {
+	return attributeString(item[w.KeyAttribute])
+}
+
+func (w *DynamoDBBatchWriter) version(item map[string]types.AttributeValue) int64 {
+	version, _ := strconv.ParseInt(attributeString(item[w.VersionAttribute]), 10, 64)
+
+	return version
+}
+
+func attributeString(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberS:
+		return v.Value
+	case *types.AttributeValueMemberN:
+		return v.Value
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fakeDynamoDB is a table that keeps the version of every item written to it with TransactWriteItems.
+// Like DynamoDB, it cancels a transaction when any item fails its condition or is given a reason by reject.
+type fakeDynamoDB struct {
+	// reject returns the cancellation reason code of an item in the call, numbered from 1, or "" to accept it
+	reject func(call int, id string) string
+	err    error
+
+	calls    [][]string
+	versions map[string]int64
+}
+
+func (fd *fakeDynamoDB) TransactWriteItems(_ context.Context, params *dynamodb.TransactWriteItemsInput,
+	_ ...func(*dynamodb.Options)) (*dynamodb.TransactWriteItemsOutput, error) {
+	ids := make([]string, len(params.TransactItems))
+	for i, item := range params.TransactItems {
+		ids[i] = attributeString(item.Put.Item["id"])
+	}
+
+	fd.calls = append(fd.calls, ids)
+
+	if fd.err != nil {
+		return nil, fd.err
+	}
+
+	reasons := make([]types.CancellationReason, len(params.TransactItems))
+	canceled := false
+
+	for i, item := range params.TransactItems {
+		code := "None"
+
+		if fd.reject != nil && fd.reject(len(fd.calls), ids[i]) != "" {
+			code = fd.reject(len(fd.calls), ids[i])
+		} else if stored, ok := fd.versions[ids[i]]; ok && item.Put.ConditionExpression != nil &&
+			stored >= itemVersion(item.Put.ExpressionAttributeValues[":version"]) {
+			code = "ConditionalCheckFailed"
+		}
+
+		if code != "None" {
+			canceled = true
+		}
+
+		reasons[i] = types.CancellationReason{Code: aws.String(code), Message: aws.String(code)}
+	}
+
+	if canceled {
+		return nil, &types.TransactionCanceledException{CancellationReasons: reasons}
+	}
+
+	if fd.versions == nil {
+		fd.versions = make(map[string]int64)
+	}
+
+	for i, item := range params.TransactItems {
+		fd.versions[ids[i]] = itemVersion(item.Put.Item["version"])
+	}
+
+	return &dynamodb.TransactWriteItemsOutput{}, nil
+}
+
+func itemVersion(av types.AttributeValue) int64 {
+	version, _ := strconv.ParseInt(attributeString(av), 10, 64)
+
+	return version
+}
+
+func testItem(id string, version int) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"id":      &types.AttributeValueMemberS{Value: id},
+		"version": &types.AttributeValueMemberN{Value: strconv.Itoa(version)},
+	}
+}
+
+func newTestBatchWriter(client DynamoDBClient, batchSize int) *DynamoDBBatchWriter {
+	return NewDynamoDBBatchWriter(client, "FootballMatches", "id", "version", batchSize, time.Hour,
+		RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+}
+
+func TestDynamoDBBatchWriterAdd(t *testing.T) {
+	w := newTestBatchWriter(&fakeDynamoDB{}, 3)
+
+	w.Add(testItem("a", 2))
+	w.Add(testItem("b", 1))
+	// DynamoDB rejects a transaction that writes the same key twice, so only the newest version is kept
+	w.Add(testItem("a", 1))
+	w.Add(testItem("b", 3))
+
+	if w.Pending() != 2 || w.Due() {
+		t.Fatalf("Pending(), Due() = %d, %t, want 2, false", w.Pending(), w.Due())
+	}
+
+	if got := []int64{w.version(w.items[0]), w.version(w.items[1])}; !slices.Equal(got, []int64{2, 3}) {
+		t.Errorf("buffered versions = %v, want the newest of each item", got)
+	}
+
+	w.Add(testItem("c", 1))
+
+	if !w.Due() {
+		t.Error("Due() = false with a full batch")
+	}
+
+	interval := NewDynamoDBBatchWriter(&fakeDynamoDB{}, "FootballMatches", "id", "version", 25, 0, RetryPolicy{})
+	interval.Add(testItem("a", 1))
+
+	if !interval.Due() {
+		t.Error("Due() = false once the flush interval has passed")
+	}
+}
+
+func TestDynamoDBBatchWriterChunks(t *testing.T) {
+	fd := &fakeDynamoDB{}
+	w := newTestBatchWriter(fd, 500)
+
+	if w.BatchSize != maxTransactItems {
+		t.Errorf("BatchSize = %d, want it capped at %d", w.BatchSize, maxTransactItems)
+	}
+
+	for i := range 130 {
+		w.Add(testItem(strconv.Itoa(i), 1))
+	}
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	if len(fd.calls) != 2 || len(fd.calls[0]) != maxTransactItems || len(fd.calls[1]) != 30 {
+		t.Errorf("TransactWriteItems calls of %d and %d items, want 100 then 30", len(fd.calls[0]), len(fd.calls[len(fd.calls)-1]))
+	}
+
+	if len(fd.versions) != 130 || w.Pending() != 0 {
+		t.Errorf("%d items written and %d still buffered, want 130 and 0", len(fd.versions), w.Pending())
+	}
+}
+
+func TestDynamoDBBatchWriterStaleVersion(t *testing.T) {
+	fd := &fakeDynamoDB{versions: map[string]int64{"a": 5, "b": 5}}
+	w := newTestBatchWriter(fd, 25)
+
+	// a replays an older version and b the stored one, so only c is newer than what is stored
+	w.Add(testItem("a", 4))
+	w.Add(testItem("b", 5))
+	w.Add(testItem("c", 1))
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v, want stale items dropped without an error", err)
+	}
+
+	want := [][]string{{"a", "b", "c"}, {"c"}}
+	if !slices.EqualFunc(fd.calls, want, slices.Equal[[]string]) {
+		t.Errorf("TransactWriteItems calls = %v, want %v", fd.calls, want)
+	}
+
+	if want := map[string]int64{"a": 5, "b": 5, "c": 1}; !maps.Equal(fd.versions, want) {
+		t.Errorf("stored versions = %v, want %v", fd.versions, want)
+	}
+
+	for _, put := range w.transaction([]map[string]types.AttributeValue{testItem("a", 6)}).TransactItems {
+		if aws.ToString(put.Put.ConditionExpression) != "attribute_not_exists(#key) OR #version < :version" {
+			t.Errorf("ConditionExpression = %q, want writes conditional on an older stored version",
+				aws.ToString(put.Put.ConditionExpression))
+		}
+	}
+}
+
+func TestDynamoDBBatchWriterContention(t *testing.T) {
+	fd := &fakeDynamoDB{
+		// b conflicts with another transaction on the first call only
+		reject: func(call int, id string) string {
+			if call == 1 && id == "b" {
+				return "TransactionConflict"
+			}
+
+			return ""
+		},
+	}
+
+	w := newTestBatchWriter(fd, 25)
+
+	w.Add(testItem("a", 1))
+	w.Add(testItem("b", 1))
+
+	if err := w.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := [][]string{{"a", "b"}, {"a", "b"}}
+	if !slices.EqualFunc(fd.calls, want, slices.Equal[[]string]) {
+		t.Errorf("TransactWriteItems calls = %v, want %v", fd.calls, want)
+	}
+
+	if len(fd.versions) != 2 {
+		t.Errorf("stored versions = %v, want a and b written", fd.versions)
+	}
+}
+
+func TestDynamoDBBatchWriterContentionExhausted(t *testing.T) {
+	fd := &fakeDynamoDB{
+		reject: func(_ int, id string) string {
+			if id == "c" {
+				return "ThrottlingError"
+			}
+
+			return ""
+		},
+	}
+
+	w := newTestBatchWriter(fd, 25)
+
+	for _, id := range []string{"a", "b", "c"} {
+		w.Add(testItem(id, 1))
+	}
+
+	err := w.Flush(context.Background())
+	if !errors.Is(err, ErrTransactionContended) {
+		t.Fatalf("Flush() error = %v, want ErrTransactionContended", err)
+	}
+
+	if len(fd.calls) != 3 {
+		t.Errorf("TransactWriteItems was called %d times, want once per retry attempt", len(fd.calls))
+	}
+
+	// a transaction is all or nothing, so every item of it is reported as failed
+	for _, id := range []string{"a", "b", "c"} {
+		if ItemError(err, id) == nil {
+			t.Errorf("Flush() error = %v, want %s failed", err, id)
+		}
+	}
+
+	if w.Pending() != 0 {
+		t.Errorf("Pending() = %d after a flush, want the failed items handed back in the error", w.Pending())
+	}
+}
+
+func TestDynamoDBBatchWriterRejectedItem(t *testing.T) {
+	fd := &fakeDynamoDB{
+		reject: func(_ int, id string) string {
+			if id == "b" {
+				return "ValidationError"
+			}
+
+			return ""
+		},
+	}
+
+	w := newTestBatchWriter(fd, 25)
+
+	w.Add(testItem("a", 1))
+	w.Add(testItem("b", 1))
+
+	err := w.Flush(context.Background())
+	if ItemError(err, "b") == nil || ItemError(err, "a") != nil {
+		t.Fatalf("Flush() error = %v, want only b failed", err)
+	}
+
+	if _, ok := fd.versions["a"]; !ok || len(fd.calls) != 2 {
+		t.Errorf("TransactWriteItems calls = %v, want a resent without b", fd.calls)
+	}
+}
+
+func TestDynamoDBBatchWriterPermanentError(t *testing.T) {
+	fd := &fakeDynamoDB{err: errors.New("ValidationException")}
+	w := newTestBatchWriter(fd, 25)
+
+	w.Add(testItem("a", 1))
+
+	err := w.Flush(context.Background())
+	if ItemError(err, "a") == nil {
+		t.Fatalf("Flush() error = %v, want a failed", err)
+	}
+
+	if len(fd.calls) != 1 {
+		t.Errorf("TransactWriteItems called %d times, want a rejected transaction not to be retried", len(fd.calls))
+	}
+}
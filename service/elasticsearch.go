@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/core/bulk"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types/enums/versiontype"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+func init() {
+	RegisterSink("elasticsearch", newElasticsearchSink, sports.TopicNewFootballMatch)
+}
+
+// bulkActionOverhead approximates the size of the action line that precedes every document in a bulk request.
+const bulkActionOverhead = 64
+
+// ElasticsearchBulkIndexer buffers documents for an index and writes them with the _bulk API,
+// once BatchSize documents or BatchBytes bytes are buffered, or FlushInterval has passed since the oldest one.
+// Documents are indexed under their own ID with external versioning, so a document only replaces
+// a stored one with an older version, and replays and out-of-order deliveries converge on the latest state.
+type ElasticsearchBulkIndexer struct {
+	Client        *elasticsearch.TypedClient
+	Index         string
+	BatchSize     int
+	BatchBytes    int
+	FlushInterval time.Duration
+	Retry         RetryPolicy
+
+	documents []bulkDocument
+	ids       map[string]int
+	bytes     int
+	oldest    time.Time
+}
+
+type bulkDocument struct {
+	id      string
+	version int64
+	body    []byte
+}
+
+// newElasticsearchSink indexes football matches into the configured Elasticsearch index, by match ID and versioned.
+func newElasticsearchSink(cfg *config.Config, _ *slog.Logger) (Sink, error) {
+	client, err := elasticsearch.NewTypedClient(*cfg.ElasticsearchConfig)
+	if err != nil {
+		return nil, errors.New("Failed to create Elasticsearch client: " + err.Error())
+	}
+
+	return NewElasticsearchBulkIndexer(
+		client,
+		cfg.ConsumerConfig.ElasticsearchIndex,
+		cfg.ConsumerConfig.ElasticsearchBatchSize,
+		cfg.ConsumerConfig.ElasticsearchBatchBytes,
+		cfg.ConsumerConfig.ElasticsearchFlushInterval,
+		newRetryPolicy(cfg.ConsumerConfig),
+	), nil
+}
+
+// NewElasticsearchBulkIndexer creates a bulk indexer for the index.
+func NewElasticsearchBulkIndexer(
+	client *elasticsearch.TypedClient,
+	index string,
+	batchSize int,
+	batchBytes int,
+	flushInterval time.Duration,
+	retry RetryPolicy,
+) *ElasticsearchBulkIndexer {
+	return &ElasticsearchBulkIndexer{
+		Client:        client,
+		Index:         index,
+		BatchSize:     batchSize,
+		BatchBytes:    batchBytes,
+		FlushInterval: flushInterval,
+		Retry:         retry,
+		ids:           make(map[string]int),
+	}
+}
+
+// Add buffers a version of a document under its ID, replacing a buffered document with the same ID unless it is older.
+func (bi *ElasticsearchBulkIndexer) Add(id string, version int64, document any) error {
+	body, err := json.Marshal(document)
+	if err != nil {
+		return errors.New("Failed to marshal document: " + err.Error())
+	}
+
+	if i, ok := bi.ids[id]; ok {
+		if version >= bi.documents[i].version {
+			bi.bytes += len(body) - len(bi.documents[i].body)
+			bi.documents[i].version = version
+			bi.documents[i].body = body
+		}
+
+		return nil
+	}
+
+	if len(bi.documents) == 0 {
+		bi.oldest = time.Now()
+	}
+
+	bi.ids[id] = len(bi.documents)
+	bi.documents = append(bi.documents, bulkDocument{id: id, version: version, body: body})
+	bi.bytes += len(body) + bulkActionOverhead
+
+	return nil
+}
+
+// Write buffers the Elasticsearch document of a football match record.
+func (bi *ElasticsearchBulkIndexer) Write(record *Record) error {
+	fm, ok := record.Value.(*sports.FootballMatch)
+	if !ok {
+		return fmt.Errorf("%w for Elasticsearch: %T", ErrUnsupportedRecord, record.Value)
+	}
+
+	return bi.Add(record.Key, record.Version, fm.ToElasticSearchDocument())
+}
+
+// Pending returns the number of buffered documents.
+func (bi *ElasticsearchBulkIndexer) Pending() int {
+	return len(bi.documents)
+}
+
+// Due reports whether the batch is full or its oldest document has waited FlushInterval.
+func (bi *ElasticsearchBulkIndexer) Due() bool {
+	return len(bi.documents) >= bi.BatchSize ||
+		(bi.BatchBytes > 0 && bi.bytes >= bi.BatchBytes) ||
+		(len(bi.documents) > 0 && time.Since(bi.oldest) >= bi.FlushInterval)
+}
+
+// Flush indexes every buffered document. Documents rejected with a transient status are retried with backoff,
+// the others are not. When some documents could not be indexed, Flush returns a *BatchError naming them.
+func (bi *ElasticsearchBulkIndexer) Flush(ctx context.Context) error {
+	failed := make(map[string]error)
+
+	for attempt := 1; len(bi.documents) > 0; attempt++ {
+		rsp, err := bi.bulk(ctx)
+		if err != nil {
+			if !IsTransient(err) || attempt >= bi.Retry.MaxAttempts {
+				for _, document := range bi.documents {
+					failed[document.id] = err
+				}
+
+				break
+			}
+		} else {
+			var retry []bulkDocument
+
+			for i, item := range rsp.Items {
+				for _, result := range item {
+					// a conflict means the same or a newer version is already indexed
+					if result.Error == nil || result.Status == http.StatusConflict {
+						continue
+					}
+
+					itemErr := &types.ElasticsearchError{ErrorCause: *result.Error, Status: result.Status}
+
+					if isTransientStatus(result.Status) && attempt < bi.Retry.MaxAttempts {
+						retry = append(retry, bi.documents[i])
+					} else {
+						failed[bi.documents[i].id] = itemErr
+					}
+				}
+			}
+
+			bi.documents = retry
+
+			if len(retry) == 0 {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			for _, document := range bi.documents {
+				failed[document.id] = ctx.Err()
+			}
+
+			bi.documents = nil
+		case <-time.After(bi.Retry.Backoff(attempt)):
+		}
+	}
+
+	bi.Discard()
+
+	if len(failed) > 0 {
+		return &BatchError{Sink: "Elasticsearch", Failed: failed}
+	}
+
+	return nil
+}
+
+// Fork returns a bulk indexer for the same index.
+func (bi *ElasticsearchBulkIndexer) Fork() Sink {
+	return NewElasticsearchBulkIndexer(bi.Client, bi.Index, bi.BatchSize, bi.BatchBytes, bi.FlushInterval, bi.Retry)
+}
+
+// Reconfigure applies the batch thresholds of the consumer settings.
+func (bi *ElasticsearchBulkIndexer) Reconfigure(consumerConfig *config.ConsumerConfig) {
+	bi.BatchSize = consumerConfig.ElasticsearchBatchSize
+	bi.BatchBytes = consumerConfig.ElasticsearchBatchBytes
+	bi.FlushInterval = consumerConfig.ElasticsearchFlushInterval
+}
+
+// Close drops every buffered document.
+func (bi *ElasticsearchBulkIndexer) Close() error {
+	bi.Discard()
+
+	return nil
+}
+
+// Discard drops every buffered document.
+func (bi *ElasticsearchBulkIndexer) Discard() {
+	bi.documents = nil
+	bi.ids = make(map[string]int)
+	bi.bytes = 0
+}
+
+func (bi *ElasticsearchBulkIndexer) bulk(ctx context.Context) (*bulk.Response, error) {
+	request := bi.Client.Bulk().Index(bi.Index)
+
+	for _, document := range bi.documents {
+		operation := types.IndexOperation{
+			Id_:         &document.id,
+			Version:     &document.version,
+			VersionType: &versiontype.External,
+		}
+
+		if err := request.IndexOp(operation, document.body); err != nil {
+			return nil, err
+		}
+	}
+
+	return request.Do(ctx)
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeBulkResponse answers a _bulk request with an item per document, with the status chosen by status.
+// Like the indexer, it expects every document to be indexed with an external version.
+func writeBulkResponse(w http.ResponseWriter, r *http.Request, status func(id string, version int64) int) {
+	var items []string
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 1<<20), 1<<20)
+
+	// the body alternates between action lines and documents
+	for action := true; scanner.Scan(); action = !action {
+		if !action {
+			continue
+		}
+
+		var line map[string]struct {
+			ID          string `json:"_id"`
+			Version     int64  `json:"version"`
+			VersionType string `json:"version_type"`
+		}
+
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil || line["index"].VersionType != "external" {
+			http.Error(w, fmt.Sprintf("unexpected action %s", scanner.Bytes()), http.StatusBadRequest)
+
+			return
+		}
+
+		id := line["index"].ID
+		s := status(id, line["index"].Version)
+
+		item := fmt.Sprintf(`{"index":{"_index":"football-matches","_id":%q,"status":%d`, id, s)
+		if s >= 300 {
+			item += fmt.Sprintf(`,"error":{"type":"error_%d","reason":"rejected"}}}`, s)
+		} else {
+			item += `,"result":"created"}}`
+		}
+
+		items = append(items, item)
+	}
+
+	_, _ = io.WriteString(w, `{"took":1,"errors":false,"items":[`+strings.Join(items, ",")+`]}`)
+}
+
+// fakeBulk records the IDs of every _bulk request and answers with the statuses it is scripted with.
+type fakeBulk struct {
+	mu sync.Mutex
+	// statuses maps a document ID to the statuses of its successive attempts, the last one repeating
+	statuses map[string][]int
+	calls    [][]string
+}
+
+func (fb *fakeBulk) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fb.mu.Lock()
+	defer fb.mu.Unlock()
+
+	var ids []string
+
+	writeBulkResponse(w, r, func(id string, _ int64) int {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+
+		attempt := 0
+		for _, call := range fb.calls {
+			if slices.Contains(call, id) {
+				attempt++
+			}
+		}
+
+		statuses, ok := fb.statuses[id]
+		if !ok {
+			return http.StatusCreated
+		}
+
+		return statuses[min(attempt, len(statuses)-1)]
+	})
+
+	fb.calls = append(fb.calls, ids)
+}
+
+func newTestBulkIndexer(t *testing.T, fb *fakeBulk, batchSize int) *ElasticsearchBulkIndexer {
+	t.Helper()
+
+	return NewElasticsearchBulkIndexer(newFakeElasticsearch(t, fb.ServeHTTP), "football-matches", batchSize, 0, time.Hour,
+		RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+}
+
+type testDocument struct {
+	Round int `json:"round"`
+}
+
+func TestElasticsearchBulkIndexerAdd(t *testing.T) {
+	bi := NewElasticsearchBulkIndexer(nil, "football-matches", 3, 0, time.Hour, RetryPolicy{})
+
+	for _, id := range []string{"a", "b", "a"} {
+		if err := bi.Add(id, 1, testDocument{Round: 1}); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	if bi.Pending() != 2 || bi.Due() {
+		t.Errorf("Pending(), Due() = %d, %t, want 2, false", bi.Pending(), bi.Due())
+	}
+
+	if err := bi.Add("c", 1, testDocument{Round: 1}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	if !bi.Due() {
+		t.Error("Due() = false with a full batch")
+	}
+
+	bytes := NewElasticsearchBulkIndexer(nil, "football-matches", 100, 50, time.Hour, RetryPolicy{})
+	_ = bytes.Add("a", 1, testDocument{Round: 1})
+
+	if !bytes.Due() {
+		t.Error("Due() = false once the batch bytes are reached")
+	}
+
+	if err := bi.Add("d", 1, func() {}); err == nil {
+		t.Error("Add() of a document that does not marshal error = nil, want an error")
+	}
+}
+
+// TestElasticsearchBulkIndexerRetriesFailedItems checks that only the items rejected with a transient status are resent.
+func TestElasticsearchBulkIndexerRetriesFailedItems(t *testing.T) {
+	fb := &fakeBulk{statuses: map[string][]int{
+		"b": {http.StatusTooManyRequests, http.StatusCreated},
+		"c": {http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusOK},
+	}}
+
+	bi := newTestBulkIndexer(t, fb, 10)
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		_ = bi.Add(id, 1, testDocument{Round: 1})
+	}
+
+	if err := bi.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	want := [][]string{{"a", "b", "c", "d"}, {"b", "c"}, {"c"}}
+	if !slices.EqualFunc(fb.calls, want, slices.Equal[[]string]) {
+		t.Errorf("_bulk requests = %v, want %v", fb.calls, want)
+	}
+
+	if bi.Pending() != 0 {
+		t.Errorf("Pending() = %d after a successful flush, want 0", bi.Pending())
+	}
+}
+
+func TestElasticsearchBulkIndexerReportsFailedItems(t *testing.T) {
+	fb := &fakeBulk{statuses: map[string][]int{
+		// rejected documents are not retried
+		"b": {http.StatusBadRequest},
+		// transient failures are retried until the attempts run out
+		"c": {http.StatusTooManyRequests},
+	}}
+
+	bi := newTestBulkIndexer(t, fb, 10)
+
+	for _, id := range []string{"a", "b", "c"} {
+		_ = bi.Add(id, 1, testDocument{Round: 1})
+	}
+
+	err := bi.Flush(context.Background())
+
+	var batchErr *BatchError
+	if !errors.As(err, &batchErr) {
+		t.Fatalf("Flush() error = %v, want a *BatchError", err)
+	}
+
+	var failed []string
+	for id := range batchErr.Failed {
+		failed = append(failed, id)
+	}
+
+	slices.Sort(failed)
+
+	if !slices.Equal(failed, []string{"b", "c"}) {
+		t.Errorf("failed documents = %v, want [b c]", failed)
+	}
+
+	want := [][]string{{"a", "b", "c"}, {"c"}, {"c"}}
+	if !slices.EqualFunc(fb.calls, want, slices.Equal[[]string]) {
+		t.Errorf("_bulk requests = %v, want %v", fb.calls, want)
+	}
+
+	if bi.Pending() != 0 {
+		t.Errorf("Pending() = %d after a flush, want the failed documents handed back in the error", bi.Pending())
+	}
+}
+
+func TestElasticsearchBulkIndexerRequestFailure(t *testing.T) {
+	requests := 0
+
+	client := newFakeElasticsearch(t, func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = io.WriteString(w, `{"error":{"type":"parse_exception","reason":"bad"},"status":400}`)
+	})
+
+	bi := NewElasticsearchBulkIndexer(client, "football-matches", 10, 0, time.Hour,
+		RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	_ = bi.Add("a", 1, testDocument{Round: 1})
+	_ = bi.Add("b", 1, testDocument{Round: 1})
+
+	var batchErr *BatchError
+	if err := bi.Flush(context.Background()); !errors.As(err, &batchErr) || len(batchErr.Failed) != 2 {
+		t.Fatalf("Flush() error = %v, want both documents failed", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("%d _bulk requests, want a rejected request not to be retried", requests)
+	}
+}
+
+// TestElasticsearchBulkIndexerStaleVersion checks that a document rejected because the same or a newer version
+// is indexed is neither retried nor reported as failed.
+func TestElasticsearchBulkIndexerStaleVersion(t *testing.T) {
+	var (
+		requests int
+		stored   = map[string]int64{"a": 5, "b": 5}
+	)
+
+	client := newFakeElasticsearch(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		writeBulkResponse(w, r, func(id string, version int64) int {
+			if version <= stored[id] {
+				return http.StatusConflict
+			}
+
+			stored[id] = version
+
+			return http.StatusCreated
+		})
+	})
+
+	bi := NewElasticsearchBulkIndexer(client, "football-matches", 10, 0, time.Hour,
+		RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+	// a replays an older version and b the indexed one, so only c is newer than what is indexed
+	_ = bi.Add("a", 4, testDocument{Round: 1})
+	_ = bi.Add("b", 5, testDocument{Round: 1})
+	_ = bi.Add("c", 1, testDocument{Round: 1})
+
+	if err := bi.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush() error = %v, want version conflicts ignored", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("%d _bulk requests, want version conflicts not to be retried", requests)
+	}
+
+	if want := map[string]int64{"a": 5, "b": 5, "c": 1}; !maps.Equal(stored, want) {
+		t.Errorf("indexed versions = %v, want %v", stored, want)
+	}
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/tuannkhoi/sport-data-feed/codec"
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/schemaregistry"
+)
+
+// NewTopicCodecs resolves the codec configured for each of the given topics.
+func NewTopicCodecs(cfg *config.Config, topics ...string) (map[string]codec.Codec, error) {
+	var registry *schemaregistry.Client
+	if cfg.SchemaRegistryConfig.URL != "" {
+		registry = schemaregistry.NewClient(*cfg.SchemaRegistryConfig)
+	}
+
+	codecs := make(map[string]codec.Codec, len(topics))
+
+	for _, topic := range topics {
+		c, err := codec.New(cfg.EncodingConfig.ForTopic(topic), registry)
+		if err != nil {
+			return nil, errors.New("Failed to create codec for topic " + topic + ": " + err.Error())
+		}
+
+		codecs[topic] = c
+	}
+
+	return codecs, nil
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+const (
+	maxIngestBodyBytes = 1 << 20
+	ingestTimeout      = 30 * time.Second
+)
+
+// IngestServer accepts externally supplied sports events over HTTP,
+// validates them and publishes them through the SportDataProducer.
+type IngestServer struct {
+	Server   *http.Server
+	Producer *SportDataProducer
+	Log      *slog.Logger
+}
+
+// IngestResponse reports the outcome of a single submission.
+type IngestResponse struct {
+	ID        string   `json:"id,omitempty"`
+	Status    string   `json:"status"`
+	Topic     string   `json:"topic,omitempty"`
+	Partition *int32   `json:"partition,omitempty"`
+	Offset    *int64   `json:"offset,omitempty"`
+	Errors    []string `json:"errors,omitempty"`
+}
+
+// Submission statuses reported in IngestResponse.
+const (
+	IngestStatusDelivered = "delivered"
+	IngestStatusInvalid   = "invalid"
+	IngestStatusFailed    = "failed"
+)
+
+// NewIngestServer creates a new IngestServer listening on the given address.
+func NewIngestServer(address string, sdp *SportDataProducer, logger *slog.Logger) *IngestServer {
+	is := &IngestServer{
+		Producer: sdp,
+		Log:      logger,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/football-matches", is.handleFootballMatch)
+	mux.HandleFunc("POST /v1/football-match-events", is.handleFootballMatchEvent)
+
+	is.Server = &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return is
+}
+
+// ListenAndServe serves the ingestion API until the server is shut down.
+func (is *IngestServer) ListenAndServe() error {
+	is.Log.Info("Ingestion API listening on " + is.Server.Addr)
+
+	if err := is.Server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Run serves the ingestion API until the context is done, then shuts the server down,
+// giving in-flight submissions up to ingestTimeout to finish.
+func (is *IngestServer) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- is.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), ingestTimeout)
+	defer cancel()
+
+	return is.Shutdown(shutdownCtx)
+}
+
+// Shutdown stops accepting submissions and waits for in-flight ones to finish.
+func (is *IngestServer) Shutdown(ctx context.Context) error {
+	return is.Server.Shutdown(ctx)
+}
+
+func (is *IngestServer) handleFootballMatch(w http.ResponseWriter, r *http.Request) {
+	fm := new(sports.FootballMatch)
+
+	if !decodeSubmission(w, r, fm, "football match") {
+		return
+	}
+
+	fm.Complete()
+
+	if err := fm.Validate(); err != nil {
+		writeInvalidSubmission(w, fm.ID.String(), err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ingestTimeout)
+	defer cancel()
+
+	tp, err := is.Producer.PublishFootballMatch(ctx, fm)
+	is.writePublished(w, fm.ID.String(), tp, err)
+}
+
+// handleFootballMatchEvent accepts an event for a match published earlier.
+// The producer does not keep matches, so only the fields that stand on their own are validated.
+func (is *IngestServer) handleFootballMatchEvent(w http.ResponseWriter, r *http.Request) {
+	ev := new(sports.FootballMatchEvent)
+
+	if !decodeSubmission(w, r, ev, "football match event") {
+		return
+	}
+
+	ev.Complete()
+
+	if err := ev.ValidateFields(); err != nil {
+		writeInvalidSubmission(w, ev.ID.String(), err)
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), ingestTimeout)
+	defer cancel()
+
+	tp, err := is.Producer.PublishFootballMatchEvent(ctx, ev)
+	is.writePublished(w, ev.ID.String(), tp, err)
+}
+
+// decodeSubmission decodes the request body into v, rejecting unknown fields.
+// It reports whether decoding succeeded, having written the 400 response if it did not.
+func decodeSubmission(w http.ResponseWriter, r *http.Request, v any, what string) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxIngestBodyBytes))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		writeIngestResponse(w, http.StatusBadRequest, &IngestResponse{
+			Status: IngestStatusInvalid,
+			Errors: []string{"Failed to decode " + what + ": " + err.Error()},
+		})
+
+		return false
+	}
+
+	return true
+}
+
+func writeInvalidSubmission(w http.ResponseWriter, id string, err error) {
+	writeIngestResponse(w, http.StatusUnprocessableEntity, &IngestResponse{
+		ID:     id,
+		Status: IngestStatusInvalid,
+		Errors: strings.Split(err.Error(), "\n"),
+	})
+}
+
+// writePublished reports the outcome of publishing the submission with the given ID.
+func (is *IngestServer) writePublished(w http.ResponseWriter, id string, tp kafka.TopicPartition, err error) {
+	if err != nil {
+		is.Log.Warn("Failed to publish submission " + id + ": " + err.Error())
+
+		status := http.StatusBadGateway
+		if errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+
+		writeIngestResponse(w, status, &IngestResponse{
+			ID:     id,
+			Status: IngestStatusFailed,
+			Errors: []string{err.Error()},
+		})
+
+		return
+	}
+
+	offset := int64(tp.Offset)
+
+	writeIngestResponse(w, http.StatusCreated, &IngestResponse{
+		ID:        id,
+		Status:    IngestStatusDelivered,
+		Topic:     *tp.Topic,
+		Partition: &tp.Partition,
+		Offset:    &offset,
+	})
+}
+
+func writeIngestResponse(w http.ResponseWriter, status int, rsp *IngestResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	// the status code has already been sent, so there is nothing left to report a write error to
+	_ = json.NewEncoder(w).Encode(rsp)
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/tuannkhoi/sport-data-feed/codec"
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// newTestIngestServer returns an ingest server whose producer points at a broker that does not exist,
+// so every message that gets past validation fails delivery.
+func newTestIngestServer(t *testing.T) *IngestServer {
+	t.Helper()
+
+	producer, err := kafka.NewProducer(&kafka.ConfigMap{
+		"bootstrap.servers":  "127.0.0.1:1",
+		"message.timeout.ms": 100,
+	})
+	if err != nil {
+		t.Fatalf("NewProducer() error = %v", err)
+	}
+
+	t.Cleanup(producer.Close)
+
+	jsonCodec, err := codec.New(codec.JSON, nil)
+	if err != nil {
+		t.Fatalf("codec.New() error = %v", err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	sdp := &SportDataProducer{
+		Producer: producer,
+		Log:      logger,
+		Codecs: map[string]codec.Codec{
+			sports.TopicNewFootballMatch:   jsonCodec,
+			sports.TopicFootballMatchEvent: jsonCodec,
+		},
+		Topics: &config.TopicConfig{},
+	}
+
+	return NewIngestServer("", sdp, logger)
+}
+
+func postIngest(t *testing.T, is *IngestServer, path, body string) (int, *IngestResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	is.Server.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, path, strings.NewReader(body)))
+
+	rsp := new(IngestResponse)
+	if err := json.NewDecoder(rec.Body).Decode(rsp); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+
+	return rec.Code, rsp
+}
+
+func TestIngestFootballMatch(t *testing.T) {
+	const valid = `{
+		"home_team": {"name": "Arsenal F.C."},
+		"away_team": {"name": "Aston Villa F.C."},
+		"round": 3,
+		"competition": "Premier League",
+		"kick_off": "2026-08-22T14:00:00Z"
+	}`
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantErrors []string
+	}{
+		{
+			name:       "malformed JSON",
+			body:       `{"home_team": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field",
+			body:       strings.Replace(valid, `"round": 3`, `"round": 3, "referee": "Michael Oliver"`, 1),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "same home and away team",
+			body:       strings.Replace(valid, "Aston Villa F.C.", "Arsenal F.C.", 1),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{"home_team and away_team must be different teams"},
+		},
+		{
+			name:       "team not in competition",
+			body:       strings.Replace(valid, "Aston Villa F.C.", "Real Madrid CF", 1),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{`away_team "Real Madrid CF" does not play in Premier League`},
+		},
+		{
+			name:       "wrong country",
+			body:       strings.Replace(valid, `"round": 3`, `"round": 3, "country": "Spain"`, 1),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{`country "Spain" does not match Premier League, expected "England"`},
+		},
+		{
+			name:       "delivery failure",
+			body:       valid,
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	is := newTestIngestServer(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, rsp := postIngest(t, is, "/v1/football-matches", tt.body)
+
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (errors %q)", status, tt.wantStatus, rsp.Errors)
+			}
+
+			if len(rsp.Errors) == 0 {
+				t.Error("response has no errors")
+			}
+
+			if tt.wantErrors != nil && strings.Join(rsp.Errors, "\n") != strings.Join(tt.wantErrors, "\n") {
+				t.Errorf("errors = %q, want %q", rsp.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
+
+func TestIngestFootballMatchEvent(t *testing.T) {
+	const valid = `{
+		"match_id": "8d3c4f8e-2b1a-4c7e-9f0a-3e5d6c7b8a91",
+		"type": "goal",
+		"minute": 23,
+		"team": {"name": "Arsenal F.C."},
+		"player": "Bukayo Saka"
+	}`
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantErrors []string
+	}{
+		{
+			name:       "malformed JSON",
+			body:       `{"type": `,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "unknown field",
+			body:       strings.Replace(valid, `"minute": 23`, `"minute": 23, "assist": "Martin Ødegaard"`, 1),
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "goal without a team",
+			body:       strings.Replace(valid, `"team": {"name": "Arsenal F.C."},`, "", 1),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{"goal requires a team"},
+		},
+		{
+			name:       "every problem reported",
+			body:       `{"type": "corner", "minute": 200}`,
+			wantStatus: http.StatusUnprocessableEntity,
+			wantErrors: []string{`unknown event type "corner"`, "match_id is required", "minute 200 is out of range"},
+		},
+		{
+			name:       "delivery failure",
+			body:       valid,
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	is := newTestIngestServer(t)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, rsp := postIngest(t, is, "/v1/football-match-events", tt.body)
+
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d (errors %q)", status, tt.wantStatus, rsp.Errors)
+			}
+
+			if len(rsp.Errors) == 0 {
+				t.Error("response has no errors")
+			}
+
+			if tt.wantErrors != nil && strings.Join(rsp.Errors, "\n") != strings.Join(tt.wantErrors, "\n") {
+				t.Errorf("errors = %q, want %q", rsp.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+-- Normalized football fixtures. Competitions, stadiums and teams are keyed by name,
+-- as the feed assigns team IDs per producer process.
+
+CREATE TABLE competitions (
+    id      BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name    TEXT NOT NULL UNIQUE,
+    country TEXT NOT NULL
+);
+
+CREATE TABLE stadiums (
+    id   BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE teams (
+    id         BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+    name       TEXT NOT NULL UNIQUE,
+    stadium_id BIGINT REFERENCES stadiums (id)
+);
+
+CREATE TABLE matches (
+    id             UUID PRIMARY KEY,
+    competition_id BIGINT NOT NULL REFERENCES competitions (id),
+    home_team_id   BIGINT NOT NULL REFERENCES teams (id),
+    away_team_id   BIGINT NOT NULL REFERENCES teams (id),
+    stadium_id     BIGINT NOT NULL REFERENCES stadiums (id),
+    round          INTEGER NOT NULL,
+    kick_off       TIMESTAMPTZ NOT NULL,
+    -- version orders the writes of the same match, see FootballMatch.Version
+    version        BIGINT NOT NULL,
+    updated_at     TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX matches_competition_kick_off ON matches (competition_id, kick_off);
+CREATE INDEX matches_home_team ON matches (home_team_id);
+CREATE INDEX matches_away_team ON matches (away_team_id);
+
+-- football_matches has the shape of sports.FootballMatch.
+CREATE VIEW football_matches AS
+SELECT m.id,
+       h.name   AS home_team_name,
+       hst.name AS home_team_stadium,
+       a.name   AS away_team_name,
+       ast.name AS away_team_stadium,
+       s.name   AS stadium,
+       m.round,
+       c.name   AS competition,
+       c.country,
+       m.kick_off,
+       m.updated_at
+FROM matches m
+JOIN competitions c ON c.id = m.competition_id
+JOIN teams h ON h.id = m.home_team_id
+JOIN teams a ON a.id = m.away_team_id
+JOIN stadiums s ON s.id = m.stadium_id
+LEFT JOIN stadiums hst ON hst.id = h.stadium_id
+LEFT JOIN stadiums ast ON ast.id = a.stadium_id;
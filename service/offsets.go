@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// offsetTracker collects the offsets of fully processed messages and commits them in batches,
+// one offset per partition, once enough messages have been processed or enough time has passed.
+type offsetTracker struct {
+	batchSize int
+	interval  time.Duration
+
+	mu         sync.Mutex
+	pending    map[topicPartition]kafka.Offset
+	processed  int
+	lastCommit time.Time
+}
+
+func newOffsetTracker(batchSize int, interval time.Duration) *offsetTracker {
+	return &offsetTracker{
+		batchSize:  batchSize,
+		interval:   interval,
+		pending:    make(map[topicPartition]kafka.Offset),
+		lastCommit: time.Now(),
+	}
+}
+
+// SetThresholds changes the batch size and the commit interval.
+func (ot *offsetTracker) SetThresholds(batchSize int, interval time.Duration) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	ot.batchSize = batchSize
+	ot.interval = interval
+}
+
+// Done marks the message at tp as processed by every sink, making its offset eligible for commit.
+func (ot *offsetTracker) Done(tp kafka.TopicPartition) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	// the committed offset is the offset of the next message to consume
+	ot.pending[topicPartition{*tp.Topic, tp.Partition}] = tp.Offset + 1
+	ot.processed++
+}
+
+// Due reports whether the batch size or the commit interval has been reached.
+func (ot *offsetTracker) Due() bool {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	return len(ot.pending) > 0 && (ot.processed >= ot.batchSize || time.Since(ot.lastCommit) >= ot.interval)
+}
+
+// Commit synchronously commits the pending offsets of the given partitions, or of all partitions when none are given.
+func (ot *offsetTracker) Commit(consumer KafkaConsumer, partitions ...kafka.TopicPartition) error {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	var offsets []kafka.TopicPartition
+
+	for key, offset := range ot.pending {
+		if len(partitions) > 0 && !containsPartition(partitions, key) {
+			continue
+		}
+
+		topic := key.topic
+		offsets = append(offsets, kafka.TopicPartition{Topic: &topic, Partition: key.partition, Offset: offset})
+	}
+
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	if _, err := consumer.CommitOffsets(offsets); err != nil {
+		var kafkaErr kafka.Error
+		if !errors.As(err, &kafkaErr) || kafkaErr.Code() != kafka.ErrNoOffset {
+			return err
+		}
+	}
+
+	for _, tp := range offsets {
+		delete(ot.pending, topicPartition{*tp.Topic, tp.Partition})
+	}
+
+	if len(ot.pending) == 0 {
+		ot.processed = 0
+	}
+
+	ot.lastCommit = time.Now()
+
+	return nil
+}
+
+func containsPartition(partitions []kafka.TopicPartition, key topicPartition) bool {
+	for _, tp := range partitions {
+		if *tp.Topic == key.topic && tp.Partition == key.partition {
+			return true
+		}
+	}
+
+	return false
+}
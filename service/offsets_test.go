@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// committingConsumer records commits and fails them with err.
+type committingConsumer struct {
+	KafkaConsumer
+	commits [][]kafka.TopicPartition
+	err     error
+}
+
+func (cc *committingConsumer) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+	cc.commits = append(cc.commits, offsets)
+
+	return offsets, cc.err
+}
+
+func topicPartitionAt(topic string, partition int32, offset kafka.Offset) kafka.TopicPartition {
+	return kafka.TopicPartition{Topic: &topic, Partition: partition, Offset: offset}
+}
+
+func TestOffsetTracker(t *testing.T) {
+	ot := newOffsetTracker(3, time.Hour)
+	cc := new(committingConsumer)
+
+	if ot.Due() {
+		t.Error("Due() = true with nothing processed")
+	}
+
+	ot.Done(topicPartitionAt("a", 0, 10))
+	ot.Done(topicPartitionAt("a", 0, 11))
+
+	if ot.Due() {
+		t.Error("Due() = true below the batch size")
+	}
+
+	ot.Done(topicPartitionAt("a", 1, 5))
+
+	if !ot.Due() {
+		t.Error("Due() = false at the batch size")
+	}
+
+	// only the revoked partition is committed
+	if err := ot.Commit(cc, topicPartitionAt("a", 1, kafka.OffsetInvalid)); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	if err := ot.Commit(cc); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+
+	var got []string
+
+	for _, batch := range cc.commits {
+		for _, tp := range batch {
+			got = append(got, partitionOffset(tp))
+		}
+	}
+
+	// committed offsets point at the next message to consume
+	if want := []string{"a/1@6", "a/0@12"}; !slices.Equal(got, want) {
+		t.Errorf("committed offsets = %v, want %v", got, want)
+	}
+
+	if ot.Due() {
+		t.Error("Due() = true after everything was committed")
+	}
+
+	if err := ot.Commit(cc); err != nil || len(cc.commits) != 2 {
+		t.Errorf("Commit() with nothing pending = %v after %d commits, want no commit", err, len(cc.commits))
+	}
+}
+
+func TestOffsetTrackerInterval(t *testing.T) {
+	ot := newOffsetTracker(100, 0)
+
+	ot.Done(topicPartitionAt("a", 0, 0))
+
+	if !ot.Due() {
+		t.Error("Due() = false once the interval has passed")
+	}
+}
+
+func TestOffsetTrackerCommitFailure(t *testing.T) {
+	ot := newOffsetTracker(1, time.Hour)
+	ot.Done(topicPartitionAt("a", 0, 0))
+
+	// the broker has nothing to commit, which is not a failure
+	cc := &committingConsumer{err: kafka.NewError(kafka.ErrNoOffset, "no offset", false)}
+	if err := ot.Commit(cc); err != nil {
+		t.Errorf("Commit() error = %v, want ErrNoOffset ignored", err)
+	}
+
+	ot.Done(topicPartitionAt("a", 0, 1))
+
+	cc.err = errors.New("coordinator not available")
+	if err := ot.Commit(cc); err == nil {
+		t.Error("Commit() error = nil, want the commit failure")
+	}
+
+	// the offset stays pending for the next commit
+	if !ot.Due() {
+		t.Error("Due() = false after a failed commit")
+	}
+}
@@ -0,0 +1,367 @@
+package service
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+//go:embed migrations/postgres/*.sql
+var postgresMigrations embed.FS
+
+// postgresMigrationLock is the advisory lock that serialises consumers migrating the same database.
+const postgresMigrationLock = 7_353_001
+
+func init() {
+	RegisterSink("postgres", newPostgresSink, sports.TopicNewFootballMatch)
+}
+
+// PostgresSink upserts football matches into normalized competitions, stadiums, teams and matches tables,
+// once BatchSize matches are buffered or FlushInterval has passed since the oldest one.
+// A match only replaces a stored one with an older version.
+type PostgresSink struct {
+	Pool          *pgxpool.Pool
+	BatchSize     int
+	FlushInterval time.Duration
+	Retry         RetryPolicy
+
+	matches map[string]*sports.FootballMatch
+	oldest  time.Time
+}
+
+// newPostgresSink connects to the configured database and, unless disabled, applies the pending migrations.
+func newPostgresSink(cfg *config.Config, logger *slog.Logger) (Sink, error) {
+	pool, err := pgxpool.New(context.Background(), cfg.ConsumerConfig.PostgresURL)
+	if err != nil {
+		return nil, errors.New("Failed to create Postgres pool: " + err.Error())
+	}
+
+	if cfg.ConsumerConfig.PostgresMigrate {
+		if err := MigratePostgres(context.Background(), pool, logger); err != nil {
+			pool.Close()
+
+			return nil, err
+		}
+	}
+
+	return NewPostgresSink(
+		pool,
+		cfg.ConsumerConfig.PostgresBatchSize,
+		cfg.ConsumerConfig.PostgresFlushInterval,
+		newRetryPolicy(cfg.ConsumerConfig),
+	), nil
+}
+
+// NewPostgresSink creates a Postgres sink writing through the pool.
+func NewPostgresSink(pool *pgxpool.Pool, batchSize int, flushInterval time.Duration, retry RetryPolicy) *PostgresSink {
+	return &PostgresSink{
+		Pool:          pool,
+		BatchSize:     batchSize,
+		FlushInterval: flushInterval,
+		Retry:         retry,
+		matches:       make(map[string]*sports.FootballMatch),
+	}
+}
+
+// Write buffers a football match record, replacing a buffered version of the same match unless it is older.
+func (ps *PostgresSink) Write(record *Record) error {
+	fm, ok := record.Value.(*sports.FootballMatch)
+	if !ok {
+		return fmt.Errorf("%w for Postgres: %T", ErrUnsupportedRecord, record.Value)
+	}
+
+	if buffered, ok := ps.matches[record.Key]; ok && buffered.Version() > fm.Version() {
+		return nil
+	}
+
+	if len(ps.matches) == 0 {
+		ps.oldest = time.Now()
+	}
+
+	ps.matches[record.Key] = fm
+
+	return nil
+}
+
+// Pending returns the number of buffered matches.
+func (ps *PostgresSink) Pending() int {
+	return len(ps.matches)
+}
+
+// Due reports whether the batch is full or its oldest match has waited FlushInterval.
+func (ps *PostgresSink) Due() bool {
+	return len(ps.matches) >= ps.BatchSize || (len(ps.matches) > 0 && time.Since(ps.oldest) >= ps.FlushInterval)
+}
+
+// Flush upserts every buffered match in a single transaction, retrying transient errors with backoff.
+func (ps *PostgresSink) Flush(ctx context.Context) error {
+	if len(ps.matches) == 0 {
+		return nil
+	}
+
+	err := ps.Retry.Do(ctx, func(ctx context.Context) error {
+		return pgx.BeginFunc(ctx, ps.Pool, func(tx pgx.Tx) error {
+			return ps.upsert(ctx, tx)
+		})
+	})
+	if err != nil {
+		failed := make(map[string]error, len(ps.matches))
+		for key := range ps.matches {
+			failed[key] = err
+		}
+
+		ps.Discard()
+
+		return &BatchError{Sink: "Postgres", Failed: failed}
+	}
+
+	ps.Discard()
+
+	return nil
+}
+
+// Discard drops every buffered match.
+func (ps *PostgresSink) Discard() {
+	ps.matches = make(map[string]*sports.FootballMatch)
+}
+
+// Fork returns a sink writing through the same pool.
+func (ps *PostgresSink) Fork() Sink {
+	return NewPostgresSink(ps.Pool, ps.BatchSize, ps.FlushInterval, ps.Retry)
+}
+
+// Reconfigure applies the batch thresholds of the consumer settings.
+func (ps *PostgresSink) Reconfigure(consumerConfig *config.ConsumerConfig) {
+	ps.BatchSize = consumerConfig.PostgresBatchSize
+	ps.FlushInterval = consumerConfig.PostgresFlushInterval
+}
+
+// Close drops every buffered match and closes the pool.
+func (ps *PostgresSink) Close() error {
+	ps.Discard()
+	ps.Pool.Close()
+
+	return nil
+}
+
+const (
+	upsertCompetitionsSQL = `
+INSERT INTO competitions (name, country)
+SELECT * FROM unnest($1::text[], $2::text[])
+ON CONFLICT (name) DO UPDATE SET country = EXCLUDED.country`
+
+	upsertStadiumsSQL = `
+INSERT INTO stadiums (name)
+SELECT * FROM unnest($1::text[])
+ON CONFLICT (name) DO NOTHING`
+
+	upsertTeamsSQL = `
+INSERT INTO teams (name, stadium_id)
+SELECT t.name, s.id
+FROM unnest($1::text[], $2::text[]) AS t (name, stadium)
+LEFT JOIN stadiums s ON s.name = t.stadium
+ON CONFLICT (name) DO UPDATE SET stadium_id = EXCLUDED.stadium_id`
+
+	upsertMatchesSQL = `
+INSERT INTO matches (id, competition_id, home_team_id, away_team_id, stadium_id, round, kick_off, version, updated_at)
+SELECT m.id, c.id, h.id, a.id, s.id, m.round, m.kick_off, m.version, m.updated_at
+FROM unnest($1::uuid[], $2::text[], $3::text[], $4::text[], $5::text[], $6::int[], $7::timestamptz[], $8::bigint[], $9::timestamptz[])
+    AS m (id, competition, home_team, away_team, stadium, round, kick_off, version, updated_at)
+JOIN competitions c ON c.name = m.competition
+JOIN teams h ON h.name = m.home_team
+JOIN teams a ON a.name = m.away_team
+JOIN stadiums s ON s.name = m.stadium
+ON CONFLICT (id) DO UPDATE SET
+    competition_id = EXCLUDED.competition_id,
+    home_team_id = EXCLUDED.home_team_id,
+    away_team_id = EXCLUDED.away_team_id,
+    stadium_id = EXCLUDED.stadium_id,
+    round = EXCLUDED.round,
+    kick_off = EXCLUDED.kick_off,
+    version = EXCLUDED.version,
+    updated_at = EXCLUDED.updated_at
+WHERE matches.version < EXCLUDED.version`
+)
+
+// upsert writes the buffered matches with one multi-row upsert per table,
+// each referenced row once, as a row cannot be upserted twice by the same statement.
+// Rows are upserted in key order, so concurrent consumers lock them in the same order and cannot deadlock.
+func (ps *PostgresSink) upsert(ctx context.Context, tx pgx.Tx) error {
+	var (
+		competitions = make(map[string]string)
+		stadiums     = make(map[string]bool)
+		teams        = make(map[string]string)
+
+		ids, matchCompetitions, homeTeams, awayTeams, matchStadiums []string
+		rounds                                                      []int32
+		kickOffs, updatedAts                                        []time.Time
+		versions                                                    []int64
+	)
+
+	_, matches := splitMap(ps.matches)
+
+	for _, fm := range matches {
+		competitions[fm.Competition] = fm.Country
+		stadiums[fm.Stadium] = true
+
+		for _, team := range []*sports.FootballTeam{fm.HomeTeam, fm.AwayTeam} {
+			teams[team.Name] = team.Stadium
+
+			if team.Stadium != "" {
+				stadiums[team.Stadium] = true
+			}
+		}
+
+		ids = append(ids, fm.ID.String())
+		matchCompetitions = append(matchCompetitions, fm.Competition)
+		homeTeams = append(homeTeams, fm.HomeTeam.Name)
+		awayTeams = append(awayTeams, fm.AwayTeam.Name)
+		matchStadiums = append(matchStadiums, fm.Stadium)
+		rounds = append(rounds, int32(fm.Round))
+		kickOffs = append(kickOffs, fm.KickOff)
+		versions = append(versions, fm.Version())
+		updatedAts = append(updatedAts, fm.UpdatedAt)
+	}
+
+	competitionNames, countries := splitMap(competitions)
+	teamNames, teamStadiums := splitMap(teams)
+	stadiumNames, _ := splitMap(stadiums)
+
+	statements := []struct {
+		sql  string
+		args []any
+	}{
+		{upsertCompetitionsSQL, []any{competitionNames, countries}},
+		{upsertStadiumsSQL, []any{stadiumNames}},
+		{upsertTeamsSQL, []any{teamNames, teamStadiums}},
+		{upsertMatchesSQL, []any{ids, matchCompetitions, homeTeams, awayTeams, matchStadiums, rounds, kickOffs, versions, updatedAts}},
+	}
+
+	for _, statement := range statements {
+		if _, err := tx.Exec(ctx, statement.sql, statement.args...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// splitMap returns the keys of m in order, along with the value of each key.
+func splitMap[V any](m map[string]V) ([]string, []V) {
+	keys := maps.Keys(m)
+
+	slices.Sort(keys)
+
+	values := make([]V, len(keys))
+	for i, key := range keys {
+		values[i] = m[key]
+	}
+
+	return keys, values
+}
+
+// MigratePostgres applies the embedded migrations that the database has not applied yet, in order.
+// Each migration runs in its own transaction and is recorded in the schema_migrations table.
+func MigratePostgres(ctx context.Context, pool *pgxpool.Pool, logger *slog.Logger) error {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return errors.New("Failed to connect to Postgres: " + err.Error())
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLock); err != nil {
+		return errors.New("Failed to lock Postgres migrations: " + err.Error())
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", postgresMigrationLock)
+
+	if _, err := conn.Exec(ctx, `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version    INTEGER PRIMARY KEY,
+    name       TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`); err != nil {
+		return errors.New("Failed to create schema_migrations table: " + err.Error())
+	}
+
+	rows, _ := conn.Query(ctx, "SELECT version FROM schema_migrations")
+
+	applied, err := pgx.CollectRows(rows, pgx.RowTo[int])
+	if err != nil {
+		return errors.New("Failed to read applied migrations: " + err.Error())
+	}
+
+	// ReadDir returns the migrations sorted by file name, which starts with the version
+	entries, err := fs.ReadDir(postgresMigrations, "migrations/postgres")
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		version, err := strconv.Atoi(strings.SplitN(entry.Name(), "_", 2)[0])
+		if err != nil {
+			return fmt.Errorf("migration %s does not start with its version", entry.Name())
+		}
+
+		if containsInt(applied, version) {
+			continue
+		}
+
+		migration, err := postgresMigrations.ReadFile("migrations/postgres/" + entry.Name())
+		if err != nil {
+			return err
+		}
+
+		if err := pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, string(migration)); err != nil {
+				return err
+			}
+
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", version, entry.Name())
+
+			return err
+		}); err != nil {
+			return fmt.Errorf("Failed to apply migration %s: %w", entry.Name(), err)
+		}
+
+		logger.Info("Applied Postgres migration " + entry.Name())
+	}
+
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isTransientPostgresError reports whether a Postgres error is worth retrying:
+// serialization failures, deadlocks, resource shortages, shutdowns and connection failures.
+func isTransientPostgresError(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return strings.HasPrefix(pgErr.Code, "08") || strings.HasPrefix(pgErr.Code, "40") ||
+			strings.HasPrefix(pgErr.Code, "53") || strings.HasPrefix(pgErr.Code, "57P")
+	}
+
+	return pgconn.SafeToRetry(err)
+}
@@ -1,16 +1,16 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
 	"log/slog"
-	"os"
-	"os/signal"
-	"syscall"
+	"sync/atomic"
 	"time"
 
 	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
 
+	"github.com/tuannkhoi/sport-data-feed/codec"
 	"github.com/tuannkhoi/sport-data-feed/config"
 	"github.com/tuannkhoi/sport-data-feed/sports"
 )
@@ -18,73 +18,190 @@ import (
 type SportDataProducer struct {
 	Producer *kafka.Producer
 	Log      *slog.Logger
+	Codecs   map[string]codec.Codec
+	// Topics names the Kafka topics that the topics of the codecs are produced to.
+	Topics *config.TopicConfig
+
+	// settings holds the producer settings that can change while Run is producing, see ApplyConfig.
+	settings     atomic.Pointer[config.ProducerConfig]
+	reconfigured chan struct{}
 }
 
 // NewSportDataProducer creates a new SportDataProducer instance.
 func NewSportDataProducer(cfg *config.Config, logger *slog.Logger) (*SportDataProducer, error) {
-	producer, err := kafka.NewProducer(cfg.KafkaConfigMap)
+	codecs, err := NewTopicCodecs(cfg, sports.TopicNewFootballMatch, sports.TopicFootballMatchEvent)
+	if err != nil {
+		return nil, err
+	}
+
+	producer, err := kafka.NewProducer(cfg.KafkaProducerConfigMap)
 	if err != nil {
 		return nil, errors.New("Failed to create Producer: " + err.Error())
 	}
 
-	return &SportDataProducer{
-		Producer: producer,
-		Log:      logger,
-	}, nil
+	sdp := &SportDataProducer{
+		Producer:     producer,
+		Log:          logger,
+		Codecs:       codecs,
+		Topics:       cfg.Topics,
+		reconfigured: make(chan struct{}, 1),
+	}
+
+	sdp.settings.Store(cfg.ProducerConfig)
+
+	return sdp, nil
 }
 
-// ProduceNewFootballMatch produces a new football match every 3 seconds.
-func (sdp *SportDataProducer) ProduceNewFootballMatch() {
-	topic := sports.TopicNewFootballMatch
+// ApplyConfig switches the running producer to the interval and leagues of the producer settings.
+func (sdp *SportDataProducer) ApplyConfig(producerConfig *config.ProducerConfig) {
+	sdp.settings.Store(producerConfig)
 
-	ticker := time.NewTicker(3 * time.Second)
-	defer ticker.Stop()
+	select {
+	case sdp.reconfigured <- struct{}{}:
+	default:
+	}
+}
 
-	sigCh := make(chan os.Signal, 1)
-	defer close(sigCh)
+// Run produces a random football match every configured interval until the context is done.
+func (sdp *SportDataProducer) Run(ctx context.Context) error {
+	topic := sports.TopicNewFootballMatch
+
+	interval := sdp.settings.Load().Interval
 
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-produceLoop:
 	for {
 		select {
-		case <-sigCh:
-			sdp.Log.Info("Received signal to close the producer. Closing...")
-
-			// send any outstanding or buffered messages to the Kafka broker and close the connection
-			sdp.Producer.Flush(15 * 1000)
-			sdp.Producer.Close()
-
-			break produceLoop
+		case <-ctx.Done():
+			return nil
+		case <-sdp.reconfigured:
+			if settings := sdp.settings.Load(); settings.Interval != interval {
+				interval = settings.Interval
+				ticker.Reset(interval)
+
+				sdp.Log.Info("Producing a football match every " + interval.String())
+			}
 		case <-ticker.C:
-			footballMatch := sports.NewFootballMatch()
+			footballMatch := sports.NewFootballMatch(sdp.settings.Load().Leagues...)
 
-			bytes, err := json.Marshal(footballMatch)
+			msg, err := sdp.newMessage(topic, footballMatch.ID.String(), footballMatch)
 			if err != nil {
 				sdp.Log.Warn("Failed to marshal football match: " + err.Error())
+
+				continue
 			}
 
 			// produces a sample message to the user-created topic
-			if err := sdp.Producer.Produce(&kafka.Message{
-				TopicPartition: kafka.TopicPartition{Topic: &topic, Partition: kafka.PartitionAny},
-				Key:            []byte(footballMatch.ID.String()),
-				Value:          bytes,
-			}, nil); err != nil {
+			if err := sdp.Producer.Produce(msg, nil); err != nil {
 				sdp.Log.Warn("Failed to produce message: " + err.Error())
 			}
 		}
 	}
 }
 
-// Monitor handle message delivery reports and possibly other event types (errors, stats, etc.,).
-func (sdp *SportDataProducer) Monitor() {
-	for e := range sdp.Producer.Events() {
-		switch ev := e.(type) {
-		case *kafka.Message:
-			if ev.TopicPartition.Error != nil {
-				sdp.Log.Warn("Failed to deliver message: " + ev.TopicPartition.Error.Error())
-			} else {
-				sdp.Log.Info("Produced event to topic " + *ev.TopicPartition.Topic)
+// Close sends any outstanding or buffered messages to the Kafka broker and closes the connection.
+func (sdp *SportDataProducer) Close() error {
+	var err error
+	if n := sdp.Producer.Flush(15 * 1000); n > 0 {
+		err = fmt.Errorf("%d messages were not delivered before the producer closed", n)
+	}
+
+	sdp.Producer.Close()
+
+	return err
+}
+
+// PublishFootballMatch produces the football match and waits for its delivery report,
+// returning the partition and offset it was written to.
+func (sdp *SportDataProducer) PublishFootballMatch(ctx context.Context, fm *sports.FootballMatch) (kafka.TopicPartition, error) {
+	msg, err := sdp.newMessage(sports.TopicNewFootballMatch, fm.ID.String(), fm)
+	if err != nil {
+		return kafka.TopicPartition{}, errors.New("Failed to marshal football match: " + err.Error())
+	}
+
+	return sdp.publish(ctx, msg)
+}
+
+// PublishFootballMatchEvent produces the football match event and waits for its delivery report.
+// Events are keyed by match ID so that all events of a match stay in order on one partition.
+func (sdp *SportDataProducer) PublishFootballMatchEvent(ctx context.Context, ev *sports.FootballMatchEvent) (kafka.TopicPartition, error) {
+	msg, err := sdp.newMessage(sports.TopicFootballMatchEvent, ev.MatchID.String(), ev)
+	if err != nil {
+		return kafka.TopicPartition{}, errors.New("Failed to marshal football match event: " + err.Error())
+	}
+
+	return sdp.publish(ctx, msg)
+}
+
+// publish produces the message with a dedicated delivery channel, so its report bypasses Monitor.
+func (sdp *SportDataProducer) publish(ctx context.Context, msg *kafka.Message) (kafka.TopicPartition, error) {
+	delivered, err := PublishSync(ctx, sdp.Producer, msg)
+	if err != nil {
+		return delivered, err
+	}
+
+	sdp.Log.Info("Produced event to topic " + *delivered.Topic)
+
+	return delivered, nil
+}
+
+// PublishSync produces the message and waits for its delivery report.
+func PublishSync(ctx context.Context, producer *kafka.Producer, msg *kafka.Message) (kafka.TopicPartition, error) {
+	deliveryCh := make(chan kafka.Event, 1)
+
+	if err := producer.Produce(msg, deliveryCh); err != nil {
+		return kafka.TopicPartition{}, errors.New("Failed to produce message: " + err.Error())
+	}
+
+	select {
+	case <-ctx.Done():
+		return kafka.TopicPartition{}, ctx.Err()
+	case e := <-deliveryCh:
+		delivered := e.(*kafka.Message).TopicPartition
+		if delivered.Error != nil {
+			return delivered, errors.New("Failed to deliver message: " + delivered.Error.Error())
+		}
+
+		return delivered, nil
+	}
+}
+
+// newMessage encodes the event with the topic's codec into a message for the topic's Kafka topic, keyed by key.
+func (sdp *SportDataProducer) newMessage(topic, key string, v any) (*kafka.Message, error) {
+	name := sdp.Topics.Name(topic)
+
+	value, err := sdp.Codecs[topic].Marshal(name, v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kafka.Message{
+		TopicPartition: kafka.TopicPartition{Topic: &name, Partition: kafka.PartitionAny},
+		Key:            []byte(key),
+		Value:          value,
+	}, nil
+}
+
+// Monitor handle message delivery reports and possibly other event types (errors, stats, etc.,)
+// until the context is done or the producer is closed.
+func (sdp *SportDataProducer) Monitor(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e, ok := <-sdp.Producer.Events():
+			if !ok {
+				return nil
+			}
+
+			switch ev := e.(type) {
+			case *kafka.Message:
+				if ev.TopicPartition.Error != nil {
+					sdp.Log.Warn("Failed to deliver message: " + ev.TopicPartition.Error.Error())
+				} else {
+					sdp.Log.Info("Produced event to topic " + *ev.TopicPartition.Topic)
+				}
 			}
 		}
 	}
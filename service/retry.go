@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+)
+
+// RetryPolicy retries operations that fail with a transient error, waiting a jittered,
+// exponentially growing backoff between attempts.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Do calls fn until it succeeds, fails with a permanent error, runs out of attempts or ctx is done.
+// It returns the last error.
+func (rp RetryPolicy) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	var err error
+
+	for attempt := 1; ; attempt++ {
+		if err = fn(ctx); err == nil || !IsTransient(err) || attempt >= rp.MaxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(rp.Backoff(attempt)):
+		}
+	}
+}
+
+// Backoff returns how long to wait after the given failed attempt: a random duration up to
+// InitialBackoff doubled for every previous attempt, capped at MaxBackoff ("full jitter").
+func (rp RetryPolicy) Backoff(attempt int) time.Duration {
+	ceiling := rp.InitialBackoff
+
+	for i := 1; i < attempt && ceiling < rp.MaxBackoff; i++ {
+		ceiling *= 2
+	}
+
+	if ceiling > rp.MaxBackoff {
+		ceiling = rp.MaxBackoff
+	}
+
+	if ceiling <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
+
+// transientErrorCodes are the AWS error codes of throttling and server-side failures.
+var transientErrorCodes = map[string]bool{
+	"ProvisionedThroughputExceededException": true,
+	"ThrottlingException":                    true,
+	"RequestLimitExceeded":                   true,
+	"InternalServerError":                    true,
+	"ServiceUnavailable":                     true,
+	"TransactionConflictException":           true,
+}
+
+// IsTransient reports whether an error from a sink is worth retrying: throttling, contended transactions,
+// server-side failures, Postgres serialization failures and network errors are,
+// while rejected requests and cancellation are not.
+func IsTransient(err error) bool {
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	if errors.Is(err, ErrTransactionContended) {
+		return true
+	}
+
+	var apiErr smithy.APIError
+	if errors.As(err, &apiErr) && transientErrorCodes[apiErr.ErrorCode()] {
+		return true
+	}
+
+	var responseErr *smithyhttp.ResponseError
+	if errors.As(err, &responseErr) {
+		return isTransientStatus(responseErr.HTTPStatusCode())
+	}
+
+	var esErr *types.ElasticsearchError
+	if errors.As(err, &esErr) {
+		return isTransientStatus(esErr.Status)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	return isTransientPostgresError(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+func isTransientStatus(status int) bool {
+	return status == 429 || status >= 500
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/elastic/go-elasticsearch/v8/typedapi/types"
+)
+
+func TestBackoff(t *testing.T) {
+	rp := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	ceilings := []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		400 * time.Millisecond,
+		800 * time.Millisecond,
+		time.Second,
+		time.Second,
+	}
+
+	for i, ceiling := range ceilings {
+		attempt := i + 1
+		seen := make(map[time.Duration]bool)
+
+		for range 1000 {
+			backoff := rp.Backoff(attempt)
+			if backoff <= 0 || backoff > ceiling {
+				t.Fatalf("Backoff(%d) = %s, want within (0, %s]", attempt, backoff, ceiling)
+			}
+
+			seen[backoff] = true
+		}
+
+		// full jitter spreads the retries of many consumers over the whole window
+		if len(seen) < 100 {
+			t.Errorf("Backoff(%d) returned only %d distinct durations in 1000 calls", attempt, len(seen))
+		}
+	}
+
+	if backoff := (RetryPolicy{}).Backoff(3); backoff != 0 {
+		t.Errorf("Backoff() without an initial backoff = %s, want 0", backoff)
+	}
+}
+
+func responseError(status int) error {
+	return &smithyhttp.ResponseError{
+		Response: &smithyhttp.Response{Response: &http.Response{StatusCode: status}},
+		Err:      errors.New("request failed"),
+	}
+}
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"throttled", &smithy.GenericAPIError{Code: "ProvisionedThroughputExceededException"}, true},
+		{"transaction conflict", &smithy.GenericAPIError{Code: "TransactionConflictException"}, true},
+		{"validation", &smithy.GenericAPIError{Code: "ValidationException"}, false},
+		{"AWS server error", responseError(http.StatusServiceUnavailable), true},
+		{"AWS bad request", responseError(http.StatusBadRequest), false},
+		{"Elasticsearch too many requests", &types.ElasticsearchError{Status: http.StatusTooManyRequests}, true},
+		{"Elasticsearch conflict", &types.ElasticsearchError{Status: http.StatusConflict}, false},
+		{"wrapped", fmt.Errorf("Failed to index: %w", &types.ElasticsearchError{Status: http.StatusBadGateway}), true},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{"deadline", context.DeadlineExceeded, true},
+		{"cancelled", context.Canceled, false},
+		{"cancelled while throttled", errors.Join(&smithy.GenericAPIError{Code: "ThrottlingException"}, context.Canceled), false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %t, want %t", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDo(t *testing.T) {
+	rp := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond}
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"success", []error{nil}, 1, false},
+		{"transient then success", []error{context.DeadlineExceeded, nil}, 2, false},
+		{"permanent", []error{errors.New("boom")}, 1, true},
+		{"out of attempts", []error{context.DeadlineExceeded, context.DeadlineExceeded, context.DeadlineExceeded}, 3, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+
+			err := rp.Do(context.Background(), func(context.Context) error {
+				calls++
+
+				return tt.errs[calls-1]
+			})
+
+			if calls != tt.wantCalls || (err != nil) != tt.wantErr {
+				t.Errorf("Do() = %v after %d calls, want error %t after %d calls", err, calls, tt.wantErr, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyDoCancelled(t *testing.T) {
+	rp := RetryPolicy{MaxAttempts: 10, InitialBackoff: time.Hour, MaxBackoff: time.Hour}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := rp.Do(ctx, func(context.Context) error { return context.DeadlineExceeded })
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Do() error = %v, want the last failure joined with the cancellation", err)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tuannkhoi/sport-data-feed/scenario"
+)
+
+// RunScenario publishes the scenario's matches and events on the normal topics as their scripted time comes,
+// returning once everything has been published or the context is cancelled.
+func (sdp *SportDataProducer) RunScenario(ctx context.Context, s *scenario.Scenario) error {
+	steps, err := s.Timeline(time.Now())
+	if err != nil {
+		return fmt.Errorf("Invalid scenario %s: %w", s.Name, err)
+	}
+
+	sdp.Log.Info(fmt.Sprintf("Running scenario %s with %d steps", s.Name, len(steps)))
+
+	for _, step := range steps {
+		timer := time.NewTimer(time.Until(step.At))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if step.Match != nil {
+			if _, err := sdp.PublishFootballMatch(ctx, step.Match); err != nil {
+				return fmt.Errorf("scenario %s: %w", s.Name, err)
+			}
+
+			sdp.Log.Info(fmt.Sprintf("Scenario %s published match %s vs %s",
+				s.Name, step.Match.HomeTeam.Name, step.Match.AwayTeam.Name))
+		}
+
+		if step.Event != nil {
+			if _, err := sdp.PublishFootballMatchEvent(ctx, step.Event); err != nil {
+				return fmt.Errorf("scenario %s: %w", s.Name, err)
+			}
+
+			sdp.Log.Info(fmt.Sprintf("Scenario %s published %s at minute %d",
+				s.Name, step.Event.Type, step.Event.Minute))
+		}
+	}
+
+	sdp.Log.Info("Scenario " + s.Name + " finished")
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"golang.org/x/exp/maps"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// ErrUnsupportedRecord is returned by a sink for a record whose value it cannot store.
+var ErrUnsupportedRecord = errors.New("unsupported record")
+
+// Sink is a storage backend that consumed records are written to in batches.
+type Sink interface {
+	// Write buffers the record, to be written with the rest of the batch on the next Flush.
+	Write(record *Record) error
+	// Pending returns the number of buffered records.
+	Pending() int
+	// Due reports whether the buffered records should be flushed now.
+	Due() bool
+	// Flush writes every buffered record. When some records could not be written,
+	// it returns a *BatchError naming their keys. The buffer is empty afterwards.
+	Flush(ctx context.Context) error
+	// Discard drops every buffered record without writing it.
+	Discard()
+	// Close releases the resources of the sink, dropping any buffered record.
+	Close() error
+	// Fork returns a sink with an empty buffer of its own that writes through the same client, for another
+	// worker to use. A fork is discarded rather than closed, as closing the original releases the shared resources.
+	Fork() Sink
+	// Reconfigure applies the batch thresholds of changed consumer settings to the following batches.
+	Reconfigure(consumerConfig *config.ConsumerConfig)
+}
+
+// Record is a decoded message on its way to the sinks.
+type Record struct {
+	Topic string
+	// Key identifies the record in every sink: a record replaces a stored one with the same key and an older Version.
+	Key     string
+	Version int64
+	Value   any
+}
+
+// NewFootballMatchRecord creates the record of a football match.
+func NewFootballMatchRecord(fm *sports.FootballMatch) *Record {
+	return &Record{
+		Topic:   sports.TopicNewFootballMatch,
+		Key:     fm.ID.String(),
+		Version: fm.Version(),
+		Value:   fm,
+	}
+}
+
+// NewFootballMatchEventRecord creates the record of a football match event. Events never change,
+// so the time they occurred at serves as their version.
+func NewFootballMatchEventRecord(ev *sports.FootballMatchEvent) *Record {
+	return &Record{
+		Topic:   sports.TopicFootballMatchEvent,
+		Key:     ev.ID.String(),
+		Version: ev.OccurredAt.UnixMilli(),
+		Value:   ev,
+	}
+}
+
+// SinkFactory creates a sink from the configuration.
+type SinkFactory func(cfg *config.Config, logger *slog.Logger) (Sink, error)
+
+var (
+	sinkFactories = make(map[string]SinkFactory)
+	sinkTopics    = make(map[string][]string)
+)
+
+// RegisterSink makes a sink available under the name used to enable it in the configuration,
+// for the topics whose records it can store.
+func RegisterSink(name string, factory SinkFactory, topics ...string) {
+	if _, ok := sinkFactories[name]; ok {
+		panic("sink " + name + " is already registered")
+	}
+
+	sinkFactories[name] = factory
+	sinkTopics[name] = topics
+}
+
+// SinkNames returns the names of every registered sink.
+func SinkNames() []string {
+	names := maps.Keys(sinkFactories)
+
+	slices.Sort(names)
+
+	return names
+}
+
+// TopicSinks returns the names of the registered sinks that can store the records of each topic,
+// which are the topics that the consumer consumes.
+func TopicSinks() map[string][]string {
+	topicSinks := make(map[string][]string)
+
+	for _, name := range SinkNames() {
+		for _, topic := range sinkTopics[name] {
+			topicSinks[topic] = append(topicSinks[topic], name)
+		}
+	}
+
+	return topicSinks
+}
+
+// NewSink creates the sink registered under the name.
+func NewSink(name string, cfg *config.Config, logger *slog.Logger) (Sink, error) {
+	factory, ok := sinkFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown sink %q, expected one of %v", name, SinkNames())
+	}
+
+	sink, err := factory(cfg, logger)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create %s sink: %w", name, err)
+	}
+
+	return sink, nil
+}
+
+// sinkHandle is an enabled sink together with its circuit breaker.
+type sinkHandle struct {
+	name    string
+	sink    Sink
+	breaker *CircuitBreaker
+}
+
+// newTopicSinks creates every sink enabled in the configuration, once each however many topics it is enabled for,
+// and returns them along with the sinks of each topic.
+func newTopicSinks(cfg *config.Config, logger *slog.Logger) ([]*sinkHandle, map[string][]*sinkHandle, error) {
+	var (
+		sinks      []*sinkHandle
+		byName     = make(map[string]*sinkHandle)
+		topicSinks = make(map[string][]*sinkHandle)
+	)
+
+	topics := maps.Keys(cfg.ConsumerConfig.Sinks)
+
+	slices.Sort(topics)
+
+	for _, topic := range topics {
+		for _, name := range cfg.ConsumerConfig.Sinks[topic] {
+			if _, ok := sinkFactories[name]; ok && !slices.Contains(sinkTopics[name], topic) {
+				closeSinks(sinks)
+
+				return nil, nil, fmt.Errorf("sink %q cannot store the records of topic %q", name, topic)
+			}
+
+			handle, ok := byName[name]
+			if !ok {
+				sink, err := NewSink(name, cfg, logger)
+				if err != nil {
+					closeSinks(sinks)
+
+					return nil, nil, err
+				}
+
+				handle = &sinkHandle{
+					name: name,
+					sink: sink,
+					breaker: NewCircuitBreaker(name,
+						cfg.ConsumerConfig.BreakerFailureThreshold, cfg.ConsumerConfig.BreakerOpenTimeout),
+				}
+
+				byName[name] = handle
+				sinks = append(sinks, handle)
+			}
+
+			topicSinks[topic] = append(topicSinks[topic], handle)
+		}
+	}
+
+	return sinks, topicSinks, nil
+}
+
+func closeSinks(sinks []*sinkHandle) error {
+	var errs []error
+
+	for _, handle := range sinks {
+		if err := handle.sink.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("Failed to close %s sink: %w", handle.name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// newRetryPolicy returns the retry policy of sink writes.
+func newRetryPolicy(consumerConfig *config.ConsumerConfig) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    consumerConfig.RetryMaxAttempts,
+		InitialBackoff: consumerConfig.RetryInitialBackoff,
+		MaxBackoff:     consumerConfig.RetryMaxBackoff,
+	}
+}
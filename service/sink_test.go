@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// countingSink is a sink that stores nothing and counts how often it was created and closed.
+type countingSink struct {
+	closed *int
+}
+
+func (s countingSink) Write(*Record) error                { return nil }
+func (s countingSink) Pending() int                       { return 0 }
+func (s countingSink) Due() bool                          { return false }
+func (s countingSink) Flush(context.Context) error        { return nil }
+func (s countingSink) Discard()                           {}
+func (s countingSink) Fork() Sink                         { return s }
+func (s countingSink) Reconfigure(*config.ConsumerConfig) {}
+
+func (s countingSink) Close() error {
+	*s.closed++
+
+	return nil
+}
+
+// registerTestSink registers a sink for the topics under name, removing it again when the test ends.
+func registerTestSink(t *testing.T, name string, created, closed *int, topics ...string) {
+	t.Helper()
+
+	RegisterSink(name, func(*config.Config, *slog.Logger) (Sink, error) {
+		*created++
+
+		return countingSink{closed: closed}, nil
+	}, topics...)
+
+	t.Cleanup(func() {
+		delete(sinkFactories, name)
+		delete(sinkTopics, name)
+	})
+}
+
+func TestTopicSinks(t *testing.T) {
+	got := TopicSinks()[sports.TopicNewFootballMatch]
+
+	for _, name := range []string{"dynamodb", "elasticsearch"} {
+		if !slices.Contains(got, name) {
+			t.Errorf("TopicSinks()[%q] = %v, want %s included", sports.TopicNewFootballMatch, got, name)
+		}
+	}
+}
+
+func TestNewTopicSinks(t *testing.T) {
+	var created, closed int
+
+	registerTestSink(t, "test-matches", &created, &closed, sports.TopicNewFootballMatch, "other-topic")
+
+	cfg := &config.Config{ConsumerConfig: &config.ConsumerConfig{Sinks: map[string][]string{
+		sports.TopicNewFootballMatch: {"test-matches"},
+		"other-topic":                {"test-matches"},
+	}}}
+
+	sinks, topicSinks, err := newTopicSinks(cfg, slog.Default())
+	if err != nil {
+		t.Fatalf("newTopicSinks() error = %v", err)
+	}
+
+	if len(sinks) != 1 || created != 1 {
+		t.Errorf("%d sinks created %d times, want a sink enabled for two topics created once", len(sinks), created)
+	}
+
+	if topicSinks[sports.TopicNewFootballMatch][0] != topicSinks["other-topic"][0] {
+		t.Error("topics enabling the same sink got different handles")
+	}
+}
+
+func TestNewTopicSinksErrors(t *testing.T) {
+	var created, closed int
+
+	registerTestSink(t, "test-matches", &created, &closed, sports.TopicNewFootballMatch)
+
+	tests := []struct {
+		name  string
+		sinks map[string][]string
+		want  string
+	}{
+		{
+			name:  "unknown sink",
+			sinks: map[string][]string{sports.TopicNewFootballMatch: {"test-matches", "nope"}},
+			want:  `unknown sink "nope"`,
+		},
+		{
+			name:  "topic the sink cannot store",
+			sinks: map[string][]string{"a-topic": {"test-matches"}, "b-topic": {"test-matches"}},
+			want:  `sink "test-matches" cannot store the records of topic "a-topic"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			created, closed = 0, 0
+
+			cfg := &config.Config{ConsumerConfig: &config.ConsumerConfig{Sinks: tt.sinks}}
+
+			_, _, err := newTopicSinks(cfg, slog.Default())
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("newTopicSinks() error = %v, want %s", err, tt.want)
+			}
+
+			if created != closed {
+				t.Errorf("%d sinks created and %d closed, want the sinks created before the error closed", created, closed)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+)
+
+// Supervisor runs components together and shuts them down together: when one component fails,
+// the others are cancelled and Run returns once all of them have stopped.
+type Supervisor struct {
+	Log *slog.Logger
+
+	components []component
+}
+
+type component struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+// NewSupervisor creates a new Supervisor with no components.
+func NewSupervisor(logger *slog.Logger) *Supervisor {
+	return &Supervisor{Log: logger}
+}
+
+// Add registers a component, which must return once its context is done.
+// A component that returns nil before then has finished its work and does not stop the others.
+func (s *Supervisor) Add(name string, run func(ctx context.Context) error) {
+	s.components = append(s.components, component{name: name, run: run})
+}
+
+// Run runs every component until the context is done or one of them fails,
+// returning the errors of the components that failed.
+func (s *Supervisor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, c := range s.components {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			err := c.run(ctx)
+
+			// a component cancelled along with the others has not failed
+			if err == nil || (ctx.Err() != nil && errors.Is(err, context.Canceled)) {
+				s.Log.Info(fmt.Sprintf("Component %s stopped", c.name))
+
+				return
+			}
+
+			s.Log.Error(fmt.Sprintf("Component %s failed, stopping the others: %s", c.name, err))
+
+			mu.Lock()
+			errs = append(errs, fmt.Errorf("%s: %w", c.name, err))
+			mu.Unlock()
+
+			cancel()
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
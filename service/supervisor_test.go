@@ -0,0 +1,145 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func newTestSupervisor() *Supervisor {
+	return NewSupervisor(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+// untilDone is a component that runs until its context is done, recording that it stopped.
+func untilDone(stopped chan<- string, name string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		<-ctx.Done()
+		stopped <- name
+
+		return ctx.Err()
+	}
+}
+
+// runSupervisor runs s in the background, failing the test if it has not returned within a few seconds.
+func runSupervisor(t *testing.T, ctx context.Context, s *Supervisor) error {
+	t.Helper()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run() did not return")
+
+		return nil
+	}
+}
+
+func TestSupervisorCancellation(t *testing.T) {
+	s := newTestSupervisor()
+	stopped := make(chan string, 2)
+
+	s.Add("consumer", untilDone(stopped, "consumer"))
+	s.Add("admin", untilDone(stopped, "admin"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+
+	// components stopped by the caller have not failed
+	if err := runSupervisor(t, ctx, s); err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+
+	if len(stopped) != 2 {
+		t.Errorf("%d components stopped, want 2", len(stopped))
+	}
+}
+
+func TestSupervisorFailure(t *testing.T) {
+	s := newTestSupervisor()
+	stopped := make(chan string, 2)
+	errBroker := errors.New("broker unreachable")
+
+	s.Add("consumer", func(ctx context.Context) error {
+		return errBroker
+	})
+	s.Add("admin", untilDone(stopped, "admin"))
+	s.Add("watcher", untilDone(stopped, "watcher"))
+
+	// the failing component stops the others and its error is returned, named after the component
+	err := runSupervisor(t, context.Background(), s)
+	if !errors.Is(err, errBroker) {
+		t.Fatalf("Run() error = %v, want %v", err, errBroker)
+	}
+
+	if want := "consumer: broker unreachable"; err.Error() != want {
+		t.Errorf("Run() error = %q, want %q", err, want)
+	}
+
+	if len(stopped) != 2 {
+		t.Errorf("%d components stopped, want 2", len(stopped))
+	}
+}
+
+func TestSupervisorFinishedComponent(t *testing.T) {
+	s := newTestSupervisor()
+	stopped := make(chan string, 1)
+
+	s.Add("migrations", func(ctx context.Context) error {
+		return nil
+	})
+	s.Add("consumer", untilDone(stopped, "consumer"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- s.Run(ctx)
+	}()
+
+	// a component that finished its work leaves the others running
+	select {
+	case err := <-done:
+		t.Fatalf("Run() returned %v after a component finished", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	cancel()
+
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v, want nil", err)
+	}
+
+	if name := <-stopped; name != "consumer" {
+		t.Errorf("stopped %q, want consumer", name)
+	}
+}
+
+func TestSupervisorMultipleFailures(t *testing.T) {
+	s := newTestSupervisor()
+	errConsumer := errors.New("consumer failed")
+	errAdmin := errors.New("admin failed")
+
+	s.Add("consumer", func(ctx context.Context) error {
+		return errConsumer
+	})
+	s.Add("admin", func(ctx context.Context) error {
+		return errAdmin
+	})
+
+	// a component failing with its own error is reported even when another failed first
+	err := runSupervisor(t, context.Background(), s)
+	if !errors.Is(err, errConsumer) || !errors.Is(err, errAdmin) {
+		t.Errorf("Run() error = %v, want both %v and %v", err, errConsumer, errAdmin)
+	}
+}
@@ -0,0 +1,403 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+
+	"github.com/tuannkhoi/sport-data-feed/config"
+	"github.com/tuannkhoi/sport-data-feed/sports"
+)
+
+// workerTick is how often an idle partition worker checks whether its sink batches or the offsets are due.
+const workerTick = 100 * time.Millisecond
+
+// partitionWorker processes the messages of one assigned partition in order, on its own goroutine,
+// writing them to sink batches of its own so that a slow sink only holds up the partitions it is slow for.
+type partitionWorker struct {
+	sdc *SportDataConsumer
+	// topic is the name in Kafka of the partition's topic, and logicalTopic the topic it is named for.
+	topic        string
+	logicalTopic string
+	partition    int32
+	messages     chan *kafka.Message
+	stop         chan struct{}
+	stopped      chan struct{}
+	sinks        []*sinkHandle
+	unflushed    []*unflushedMessage
+	attempts     *attemptCounter
+	// applied is the consumer settings that the sinks were last reconfigured with.
+	applied *config.ConsumerConfig
+	// rewoundTo is the offset the partition was last rewound to. The messages queued before the rewind
+	// are skipped until the message at this offset is redelivered.
+	rewoundTo kafka.Offset
+	// seekFailed is set while the partition could not be rewound, so that the rewind is tried again at reseekAt
+	// after seekFailures failed attempts. Meanwhile every message is skipped, as processing the messages
+	// after the failed one would lose it.
+	seekFailed   bool
+	seekFailures int
+	reseekAt     time.Time
+	// stopping is set while the worker drains before its partition is revoked, when failed messages are left
+	// to the next owner of the partition instead of being retried.
+	stopping bool
+
+	// backlog holds the fetched messages that did not fit in the queue, while the partition is throttled.
+	// Only the goroutine polling the consumer touches backlog and throttled.
+	backlog   []*kafka.Message
+	throttled bool
+}
+
+func newPartitionWorker(sdc *SportDataConsumer, tp kafka.TopicPartition) *partitionWorker {
+	logicalTopic, _ := sdc.Topics.Logical(*tp.Topic)
+
+	var sinks []*sinkHandle
+
+	for _, handle := range sdc.topicSinks[logicalTopic] {
+		sinks = append(sinks, &sinkHandle{name: handle.name, sink: handle.sink.Fork(), breaker: handle.breaker})
+	}
+
+	return &partitionWorker{
+		sdc:          sdc,
+		topic:        *tp.Topic,
+		logicalTopic: logicalTopic,
+		partition:    tp.Partition,
+		messages:     make(chan *kafka.Message, sdc.QueueSize),
+		stop:         make(chan struct{}),
+		stopped:      make(chan struct{}),
+		sinks:        sinks,
+		attempts:     newAttemptCounter(),
+		applied:      sdc.applied,
+		rewoundTo:    kafka.OffsetInvalid,
+	}
+}
+
+func (w *partitionWorker) topicPartition() kafka.TopicPartition {
+	return kafka.TopicPartition{Topic: &w.topic, Partition: w.partition}
+}
+
+// run processes queued messages until the worker is stopped, then drains it.
+func (w *partitionWorker) run() {
+	defer close(w.stopped)
+
+	ticker := time.NewTicker(workerTick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			w.drain()
+
+			return
+		case msg := <-w.messages:
+			w.handle(msg)
+		case <-ticker.C:
+			if w.seekFailed && !time.Now().Before(w.reseekAt) {
+				w.rewind(kafka.TopicPartition{Topic: &w.topic, Partition: w.partition, Offset: w.rewoundTo})
+			}
+
+			w.reconfigure()
+			w.flushIfDue()
+			w.sdc.commitIfDue()
+		}
+	}
+}
+
+// reconfigure applies changed consumer settings to the sinks of the partition.
+func (w *partitionWorker) reconfigure() {
+	consumerConfig := w.sdc.settings.Load()
+	if consumerConfig == w.applied {
+		return
+	}
+
+	for _, handle := range w.sinks {
+		handle.sink.Reconfigure(consumerConfig)
+	}
+
+	w.applied = consumerConfig
+}
+
+// drain flushes the sink batches and commits the offsets of the partition. Queued messages are left
+// to be redelivered to the next owner of the partition.
+func (w *partitionWorker) drain() {
+	w.stopping = true
+
+	w.flush()
+	w.sdc.commit(w.topicPartition())
+}
+
+func (w *partitionWorker) handle(msg *kafka.Message) {
+	if w.rewoundTo != kafka.OffsetInvalid {
+		if w.seekFailed || msg.TopicPartition.Offset != w.rewoundTo {
+			return
+		}
+
+		w.rewoundTo = kafka.OffsetInvalid
+	}
+
+	w.sdc.Log.Debug("Consumed message",
+		"topic", w.topic, "partition", w.partition, "offset", int64(msg.TopicPartition.Offset), "key", string(msg.Key))
+
+	state, key := w.processMessage(msg)
+	if state == messageRetry {
+		w.retry(msg.TopicPartition)
+
+		return
+	}
+
+	um := &unflushedMessage{msg: msg, key: key}
+	if key != "" {
+		um.waiting = slices.Clone(w.sinks)
+	}
+
+	w.unflushed = append(w.unflushed, um)
+	w.flushIfDue()
+	w.sdc.commitIfDue()
+}
+
+// processMessage decodes the message and adds it to the sink batches, applying the failure policy when that fails.
+// A buffered message is returned with the key of its record in the batches.
+func (w *partitionWorker) processMessage(msg *kafka.Message) (messageState, string) {
+	switch w.logicalTopic {
+	case sports.TopicNewFootballMatch:
+		fm := new(sports.FootballMatch)
+
+		// retrying cannot fix a payload that does not decode or is invalid, so these go straight to the dead-letter topic
+		if err := w.sdc.Codecs[w.logicalTopic].Unmarshal(w.topic, msg.Value, fm); err != nil {
+			return finished(w.sdc.deadLetter(msg, errors.New("Failed to unmarshal football match: "+err.Error()), 1)), ""
+		}
+
+		if err := fm.Validate(); err != nil {
+			return finished(w.sdc.deadLetter(msg, errors.New("Invalid football match: "+err.Error()), 1)), ""
+		}
+
+		// matches published before updated_at existed are ordered by the time their message was produced
+		if fm.UpdatedAt.IsZero() {
+			fm.UpdatedAt = msg.Timestamp
+		}
+
+		if err := w.handleNewFootballMatch(fm); err != nil {
+			return finished(w.handleFailure(msg, fmt.Errorf("Failed to handle new football match: %w", err))), ""
+		}
+
+		return messageBuffered, fm.ID.String()
+	case sports.TopicFootballMatchEvent:
+		ev := new(sports.FootballMatchEvent)
+
+		if err := w.sdc.Codecs[w.logicalTopic].Unmarshal(w.topic, msg.Value, ev); err != nil {
+			return finished(w.sdc.deadLetter(msg, errors.New("Failed to unmarshal football match event: "+err.Error()), 1)), ""
+		}
+
+		if err := w.write(NewFootballMatchEventRecord(ev)); err != nil {
+			return finished(w.handleFailure(msg, fmt.Errorf("Failed to handle football match event: %w", err))), ""
+		}
+
+		return messageBuffered, ev.ID.String()
+	}
+
+	return messageDone, ""
+}
+
+func (w *partitionWorker) handleNewFootballMatch(fm *sports.FootballMatch) error {
+	w.sdc.Log.Debug("Decoded football match",
+		"id", fm.ID,
+		"home_team", fm.HomeTeam.Name,
+		"away_team", fm.AwayTeam.Name,
+		"stadium", fm.Stadium,
+		"round", fm.Round,
+		"competition", fm.Competition,
+		"country", fm.Country,
+		"kick_off", fm.KickOff,
+		"updated_at", fm.UpdatedAt)
+
+	return w.write(NewFootballMatchRecord(fm))
+}
+
+// write adds the record to the batch of every sink of the partition.
+func (w *partitionWorker) write(record *Record) error {
+	for _, handle := range w.sinks {
+		if err := handle.sink.Write(record); err != nil {
+			return fmt.Errorf("Failed to write to %s sink: %w", handle.name, err)
+		}
+	}
+
+	return nil
+}
+
+// handleFailure applies the failure policy to a message that a sink failed to write.
+func (w *partitionWorker) handleFailure(msg *kafka.Message, cause error) bool {
+	// the sink is known to be down, so this does not count as an attempt at the message
+	if errors.Is(cause, ErrCircuitOpen) {
+		w.sdc.Log.Warn(cause.Error() + ", waiting for the sink to recover")
+
+		return false
+	}
+
+	attempts := w.attempts.Add(msg.TopicPartition)
+
+	switch w.sdc.FailurePolicy {
+	case FailurePolicySkip:
+		w.sdc.Log.Error(cause.Error() + ", skipping it")
+
+		return true
+	case FailurePolicyDeadLetter:
+		if attempts >= w.sdc.MaxAttempts {
+			return w.sdc.deadLetter(msg, cause, attempts)
+		}
+	}
+
+	w.sdc.Log.Error(fmt.Sprintf("%s, retrying (attempt %d)", cause, attempts))
+
+	return false
+}
+
+// retry rewinds the partition to the failed message so that it is redelivered, either once a backoff
+// based on its attempts has passed or, when a sink is down, once consumption resumes.
+func (w *partitionWorker) retry(tp kafka.TopicPartition) {
+	w.rewind(tp)
+	w.backOff(tp)
+}
+
+// rewind seeks the partition back to the message at tp. When the seek fails, the worker stops processing
+// the partition and tries again with backoff, as the messages after tp must not be processed before it.
+func (w *partitionWorker) rewind(tp kafka.TopicPartition) {
+	if w.stopping {
+		return
+	}
+
+	w.rewoundTo = tp.Offset
+
+	if err := w.sdc.Consumer.Seek(tp, 0); err != nil {
+		w.seekFailures++
+		w.seekFailed = true
+		w.reseekAt = time.Now().Add(w.sdc.Retry.Backoff(w.seekFailures))
+
+		w.sdc.Log.Error(fmt.Sprintf("Failed to rewind %s [%d] to offset %s for retry, stopped the partition until it can be: %s",
+			w.topic, w.partition, tp.Offset, err))
+
+		return
+	}
+
+	if w.seekFailed {
+		w.sdc.Log.Info(fmt.Sprintf("Rewound %s [%d] to offset %s", w.topic, w.partition, tp.Offset))
+	}
+
+	w.seekFailed = false
+	w.seekFailures = 0
+}
+
+func (w *partitionWorker) backOff(tp kafka.TopicPartition) {
+	if w.stopping {
+		return
+	}
+
+	if w.sdc.sinkDown() {
+		w.sdc.pause()
+
+		return
+	}
+
+	select {
+	case <-time.After(w.sdc.Retry.Backoff(w.attempts.Count(tp))):
+	case <-w.stop:
+	}
+}
+
+// flushIfDue flushes the sink batches that are due, then settles the messages that no batch holds anymore.
+// Each sink flushes on its own schedule, so a sink holding records for longer only delays their offsets.
+func (w *partitionWorker) flushIfDue() {
+	w.flushSinks(func(handle *sinkHandle) bool {
+		return handle.sink.Due()
+	})
+}
+
+// flush flushes every sink batch and settles the messages processed since, as before the partition is revoked.
+func (w *partitionWorker) flush() {
+	w.flushSinks(func(*sinkHandle) bool {
+		return true
+	})
+}
+
+func (w *partitionWorker) flushSinks(due func(handle *sinkHandle) bool) {
+	errs := make(map[*sinkHandle]error)
+
+	for _, handle := range w.sinks {
+		if !due(handle) {
+			continue
+		}
+
+		records := handle.sink.Pending()
+		if records == 0 {
+			errs[handle] = nil
+
+			continue
+		}
+
+		if err := w.sdc.throughBreaker(handle.breaker, handle.sink.Flush); err != nil {
+			// an open breaker leaves the batch unwritten, it is redelivered with its messages
+			if errors.Is(err, ErrCircuitOpen) {
+				handle.sink.Discard()
+			}
+
+			errs[handle] = fmt.Errorf("Failed to flush %s sink: %w", handle.name, err)
+
+			continue
+		}
+
+		errs[handle] = nil
+
+		w.sdc.Log.Info(fmt.Sprintf("Successfully wrote %d records of %s [%d] to the %s sink",
+			records, w.topic, w.partition, handle.name))
+	}
+
+	for _, um := range w.unflushed {
+		um.waiting = slices.DeleteFunc(um.waiting, func(handle *sinkHandle) bool {
+			err, flushed := errs[handle]
+			if flushed && um.err == nil {
+				if itemErr := ItemError(err, um.key); itemErr != nil {
+					um.err = fmt.Errorf("Failed to flush record %s: %w", um.key, itemErr)
+				}
+			}
+
+			return flushed
+		})
+	}
+
+	w.settle()
+}
+
+// settle finishes the flushed messages in order, stopping at the first message that a batch still holds.
+// A message that must be retried rewinds the partition to it, and the messages after it are left to be redelivered.
+func (w *partitionWorker) settle() {
+	for i, um := range w.unflushed {
+		if len(um.waiting) > 0 {
+			w.unflushed = w.unflushed[i:]
+
+			return
+		}
+
+		tp := um.msg.TopicPartition
+
+		if um.err == nil || w.handleFailure(um.msg, um.err) {
+			w.done(tp)
+
+			continue
+		}
+
+		w.unflushed = nil
+
+		w.retry(tp)
+
+		return
+	}
+
+	w.unflushed = nil
+}
+
+// done marks the message at tp as finished with, making its offset eligible for commit.
+func (w *partitionWorker) done(tp kafka.TopicPartition) {
+	w.attempts.Forget(tp)
+	w.sdc.offsets.Done(tp)
+}
@@ -0,0 +1,158 @@
+package sports
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FootballMatchAvroRecord struct {
+	ID          string                  `avro:"id"`
+	HomeTeam    *FootballTeamAvroRecord `avro:"home_team"`
+	AwayTeam    *FootballTeamAvroRecord `avro:"away_team"`
+	Stadium     string                  `avro:"stadium"`
+	Round       int                     `avro:"round"`
+	Competition string                  `avro:"competition"`
+	Country     string                  `avro:"country"`
+	KickOff     time.Time               `avro:"kick_off"`
+	// UpdatedAt defaults to the Unix epoch for records written before it existed.
+	UpdatedAt time.Time `avro:"updated_at"`
+}
+
+type FootballTeamAvroRecord struct {
+	ID      string `avro:"id"`
+	Name    string `avro:"name"`
+	Stadium string `avro:"stadium"`
+}
+
+type FootballMatchEventAvroRecord struct {
+	ID         string                  `avro:"id"`
+	MatchID    string                  `avro:"match_id"`
+	Type       string                  `avro:"type"`
+	Minute     int                     `avro:"minute"`
+	Team       *FootballTeamAvroRecord `avro:"team"`
+	Player     string                  `avro:"player"`
+	OccurredAt time.Time               `avro:"occurred_at"`
+}
+
+func (fm *FootballMatch) ToAvroRecord() *FootballMatchAvroRecord {
+	return &FootballMatchAvroRecord{
+		ID:          fm.ID.String(),
+		HomeTeam:    fm.HomeTeam.ToAvroRecord(),
+		AwayTeam:    fm.AwayTeam.ToAvroRecord(),
+		Stadium:     fm.Stadium,
+		Round:       fm.Round,
+		Competition: fm.Competition,
+		Country:     fm.Country,
+		KickOff:     fm.KickOff,
+		UpdatedAt:   fm.UpdatedAt,
+	}
+}
+
+func (ft *FootballTeam) ToAvroRecord() *FootballTeamAvroRecord {
+	if ft == nil {
+		return nil
+	}
+
+	return &FootballTeamAvroRecord{
+		ID:      ft.ID.String(),
+		Name:    ft.Name,
+		Stadium: ft.Stadium,
+	}
+}
+
+// FootballMatchFromAvroRecord converts an Avro football match record back into a FootballMatch.
+func FootballMatchFromAvroRecord(rec *FootballMatchAvroRecord) (*FootballMatch, error) {
+	id, err := uuid.Parse(rec.ID)
+	if err != nil {
+		return nil, errors.New("Invalid football match ID: " + err.Error())
+	}
+
+	homeTeam, err := FootballTeamFromAvroRecord(rec.HomeTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	awayTeam, err := FootballTeamFromAvroRecord(rec.AwayTeam)
+	if err != nil {
+		return nil, err
+	}
+
+	fm := &FootballMatch{
+		SchemaVersion: FootballMatchSchemaVersion,
+		ID:            id,
+		HomeTeam:      homeTeam,
+		AwayTeam:      awayTeam,
+		Stadium:       rec.Stadium,
+		Round:         rec.Round,
+		Competition:   rec.Competition,
+		Country:       rec.Country,
+		KickOff:       rec.KickOff,
+	}
+
+	if rec.UpdatedAt.UnixMilli() != 0 {
+		fm.UpdatedAt = rec.UpdatedAt
+	}
+
+	return fm, nil
+}
+
+// FootballTeamFromAvroRecord converts an Avro football team record back into a FootballTeam.
+func FootballTeamFromAvroRecord(rec *FootballTeamAvroRecord) (*FootballTeam, error) {
+	if rec == nil {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(rec.ID)
+	if err != nil {
+		return nil, errors.New("Invalid football team ID: " + err.Error())
+	}
+
+	return &FootballTeam{
+		ID:      id,
+		Name:    rec.Name,
+		Stadium: rec.Stadium,
+	}, nil
+}
+
+func (ev *FootballMatchEvent) ToAvroRecord() *FootballMatchEventAvroRecord {
+	return &FootballMatchEventAvroRecord{
+		ID:         ev.ID.String(),
+		MatchID:    ev.MatchID.String(),
+		Type:       string(ev.Type),
+		Minute:     ev.Minute,
+		Team:       ev.Team.ToAvroRecord(),
+		Player:     ev.Player,
+		OccurredAt: ev.OccurredAt,
+	}
+}
+
+// FootballMatchEventFromAvroRecord converts an Avro football match event record back into a FootballMatchEvent.
+func FootballMatchEventFromAvroRecord(rec *FootballMatchEventAvroRecord) (*FootballMatchEvent, error) {
+	id, err := uuid.Parse(rec.ID)
+	if err != nil {
+		return nil, errors.New("Invalid football match event ID: " + err.Error())
+	}
+
+	matchID, err := uuid.Parse(rec.MatchID)
+	if err != nil {
+		return nil, errors.New("Invalid football match ID: " + err.Error())
+	}
+
+	team, err := FootballTeamFromAvroRecord(rec.Team)
+	if err != nil {
+		return nil, err
+	}
+
+	return &FootballMatchEvent{
+		SchemaVersion: FootballMatchEventSchemaVersion,
+		ID:            id,
+		MatchID:       matchID,
+		Type:          FootballMatchEventType(rec.Type),
+		Minute:        rec.Minute,
+		Team:          team,
+		Player:        rec.Player,
+		OccurredAt:    rec.OccurredAt,
+	}, nil
+}
@@ -0,0 +1,134 @@
+package sports
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	TopicFootballMatchEvent = "football-match-event"
+)
+
+// FootballMatchEventSchemaVersion is the version of the FootballMatchEvent JSON contract produced by this build.
+//
+// Version history:
+//   - 1: initial contract.
+const FootballMatchEventSchemaVersion = 1
+
+type FootballMatchEventType string
+
+const (
+	FootballMatchEventKickOff    FootballMatchEventType = "kick_off"
+	FootballMatchEventGoal       FootballMatchEventType = "goal"
+	FootballMatchEventYellowCard FootballMatchEventType = "yellow_card"
+	FootballMatchEventRedCard    FootballMatchEventType = "red_card"
+	FootballMatchEventHalfTime   FootballMatchEventType = "half_time"
+	FootballMatchEventFullTime   FootballMatchEventType = "full_time"
+	FootballMatchEventAbandoned  FootballMatchEventType = "abandoned"
+	FootballMatchEventPostponed  FootballMatchEventType = "postponed"
+)
+
+var footballMatchEventTypes = []FootballMatchEventType{
+	FootballMatchEventKickOff,
+	FootballMatchEventGoal,
+	FootballMatchEventYellowCard,
+	FootballMatchEventRedCard,
+	FootballMatchEventHalfTime,
+	FootballMatchEventFullTime,
+	FootballMatchEventAbandoned,
+	FootballMatchEventPostponed,
+}
+
+// FootballMatchEvent is something that happens during, or to, a football match.
+type FootballMatchEvent struct {
+	SchemaVersion int                    `json:"schema_version,omitempty"`
+	ID            uuid.UUID              `json:"id"`
+	MatchID       uuid.UUID              `json:"match_id"`
+	Type          FootballMatchEventType `json:"type"`
+	Minute        int                    `json:"minute"`
+	Team          *FootballTeam          `json:"team,omitempty"`
+	Player        string                 `json:"player,omitempty"`
+	OccurredAt    time.Time              `json:"occurred_at"`
+}
+
+// NewFootballMatchEvent creates a new event of the given type for the match.
+func NewFootballMatchEvent(fm *FootballMatch, eventType FootballMatchEventType, minute int) *FootballMatchEvent {
+	return &FootballMatchEvent{
+		SchemaVersion: FootballMatchEventSchemaVersion,
+		ID:            uuid.New(),
+		MatchID:       fm.ID,
+		Type:          eventType,
+		Minute:        minute,
+		OccurredAt:    time.Now(),
+	}
+}
+
+// Complete fills in the fields an externally supplied event may leave out: a new ID and the time it occurred.
+func (ev *FootballMatchEvent) Complete() {
+	ev.SchemaVersion = FootballMatchEventSchemaVersion
+
+	if ev.ID == uuid.Nil {
+		ev.ID = uuid.New()
+	}
+
+	if ev.OccurredAt.IsZero() {
+		ev.OccurredAt = time.Now()
+	}
+}
+
+// ValidateFields checks the parts of the event that do not depend on the match it belongs to,
+// for when the match is not at hand, and reports every violation at once.
+func (ev *FootballMatchEvent) ValidateFields() error {
+	var errs []error
+
+	if !slices.Contains(footballMatchEventTypes, ev.Type) {
+		errs = append(errs, fmt.Errorf("unknown event type %q", ev.Type))
+	}
+
+	if ev.MatchID == uuid.Nil {
+		errs = append(errs, errors.New("match_id is required"))
+	}
+
+	// 120 minutes including extra time, plus stoppage time
+	if ev.Minute < 0 || ev.Minute > 130 {
+		errs = append(errs, fmt.Errorf("minute %d is out of range", ev.Minute))
+	}
+
+	switch {
+	case ev.hasTeam() && ev.Team == nil:
+		errs = append(errs, fmt.Errorf("%s requires a team", ev.Type))
+	case !ev.hasTeam() && ev.Team != nil:
+		errs = append(errs, errors.New(string(ev.Type)+" cannot have a team"))
+	}
+
+	return errors.Join(errs...)
+}
+
+// Validate checks that the event is consistent with the match it belongs to.
+func (ev *FootballMatchEvent) Validate(fm *FootballMatch) error {
+	errs := []error{ev.ValidateFields()}
+
+	if ev.MatchID != fm.ID {
+		errs = append(errs, fmt.Errorf("event belongs to match %s, not %s", ev.MatchID, fm.ID))
+	}
+
+	if ev.hasTeam() && ev.Team != nil && ev.Team.Name != fm.HomeTeam.Name && ev.Team.Name != fm.AwayTeam.Name {
+		errs = append(errs, fmt.Errorf("team %q does not play in this match", ev.Team.Name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// hasTeam reports whether events of this type are attributed to one of the two teams.
+func (ev *FootballMatchEvent) hasTeam() bool {
+	switch ev.Type {
+	case FootballMatchEventGoal, FootballMatchEventYellowCard, FootballMatchEventRedCard:
+		return true
+	default:
+		return false
+	}
+}
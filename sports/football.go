@@ -2,6 +2,7 @@ package sports
 
 import (
 	"math/rand"
+	"slices"
 	"strconv"
 	"time"
 
@@ -16,14 +17,18 @@ const (
 )
 
 type FootballMatch struct {
-	ID          uuid.UUID     `json:"id"`
-	HomeTeam    *FootballTeam `json:"home_team"`
-	AwayTeam    *FootballTeam `json:"away_team"`
-	Stadium     string        `json:"stadium"`
-	Round       int           `json:"round"`
-	Competition string        `json:"competition"`
-	Country     string        `json:"country"`
-	KickOff     time.Time     `json:"kick_off"`
+	SchemaVersion int           `json:"schema_version,omitempty"`
+	ID            uuid.UUID     `json:"id"`
+	HomeTeam      *FootballTeam `json:"home_team"`
+	AwayTeam      *FootballTeam `json:"away_team"`
+	Stadium       string        `json:"stadium"`
+	Round         int           `json:"round"`
+	Competition   string        `json:"competition"`
+	Country       string        `json:"country"`
+	KickOff       time.Time     `json:"kick_off"`
+	// UpdatedAt is when this state of the match was published, ordering the writes of the same match.
+	// It is zero for matches published before it existed.
+	UpdatedAt time.Time `json:"updated_at" schema:"optional"`
 }
 
 type FootballTeam struct {
@@ -32,6 +37,8 @@ type FootballTeam struct {
 	Stadium string    `json:"stadium"`
 }
 
+// FootballMatchElasticSearchDocument is a match as indexed into Elasticsearch.
+// KickOff is in Unix seconds, UpdatedAt in Unix milliseconds like the version of the match.
 type FootballMatchElasticSearchDocument struct {
 	ID           string `json:"id"`
 	HomeTeamName string `json:"home_team_name"`
@@ -43,6 +50,13 @@ type FootballMatchElasticSearchDocument struct {
 	Competition  string `json:"competition"`
 	Country      string `json:"country"`
 	KickOff      int64  `json:"kick_off"`
+	UpdatedAt    int64  `json:"updated_at"`
+}
+
+// Version returns the version of this state of the match, which increases with every update:
+// the time it was published, in milliseconds.
+func (fm *FootballMatch) Version() int64 {
+	return fm.UpdatedAt.UnixMilli()
 }
 
 func (fm *FootballMatch) ToDynamoDBItem() map[string]types.AttributeValue {
@@ -57,6 +71,7 @@ func (fm *FootballMatch) ToDynamoDBItem() map[string]types.AttributeValue {
 		"round":          &types.AttributeValueMemberN{Value: strconv.Itoa(fm.Round)},
 		"competition":    &types.AttributeValueMemberS{Value: fm.Competition},
 		"country":        &types.AttributeValueMemberS{Value: fm.Country},
+		"version":        &types.AttributeValueMemberN{Value: strconv.FormatInt(fm.Version(), 10)},
 	}
 }
 
@@ -72,11 +87,28 @@ func (fm *FootballMatch) ToElasticSearchDocument() *FootballMatchElasticSearchDo
 		Competition:  fm.Competition,
 		Country:      fm.Country,
 		KickOff:      fm.KickOff.Unix(),
+		UpdatedAt:    fm.UpdatedAt.UnixMilli(),
 	}
 }
 
-func NewFootballMatch() *FootballMatch {
-	competition := getRandomElement(footballLeagues)
+// NewFootballMatch generates a random match in one of the given leagues, or in any league when none are given.
+// Unknown leagues are ignored.
+func NewFootballMatch(leagues ...string) *FootballMatch {
+	candidates := footballLeagues
+
+	if len(leagues) > 0 {
+		candidates = slices.DeleteFunc(slices.Clone(leagues), func(league string) bool {
+			_, ok := teamsByLeague[league]
+
+			return !ok
+		})
+
+		if len(candidates) == 0 {
+			candidates = footballLeagues
+		}
+	}
+
+	competition := getRandomElement(candidates)
 
 	teams := teamsByLeague[competition]
 
@@ -88,17 +120,28 @@ func NewFootballMatch() *FootballMatch {
 	}
 
 	return &FootballMatch{
-		ID:          uuid.New(),
-		HomeTeam:    homeTeam,
-		AwayTeam:    awayTeam,
-		Stadium:     homeTeam.Stadium,
-		Round:       getRandomRoundNumber(len(teams)),
-		Competition: competition,
-		Country:     countryByLeague[competition],
-		KickOff:     faker.Time().Forward(7 * 24 * time.Hour),
+		SchemaVersion: FootballMatchSchemaVersion,
+		ID:            uuid.New(),
+		HomeTeam:      homeTeam,
+		AwayTeam:      awayTeam,
+		Stadium:       homeTeam.Stadium,
+		Round:         getRandomRoundNumber(len(teams)),
+		Competition:   competition,
+		Country:       countryByLeague[competition],
+		KickOff:       faker.Time().Forward(7 * 24 * time.Hour),
+		UpdatedAt:     time.Now(),
 	}
 }
 
+// FootballLeagues returns the names of the leagues that matches can be generated for.
+func FootballLeagues() []string {
+	leagues := slices.Clone(footballLeagues)
+
+	slices.Sort(leagues)
+
+	return leagues
+}
+
 func getRandomElement[T any](arr []T) T {
 	// Create a new rand
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
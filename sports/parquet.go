@@ -0,0 +1,79 @@
+package sports
+
+// SportFootball is the sport that football records are archived under.
+const SportFootball = "football"
+
+// FootballMatchParquetRow is the row of a football match in the Parquet archive. Timestamps are in Unix milliseconds.
+type FootballMatchParquetRow struct {
+	SchemaVersion int32  `parquet:"name=schema_version, type=INT32"`
+	ID            string `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	HomeTeamID    string `parquet:"name=home_team_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	HomeTeamName  string `parquet:"name=home_team_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AwayTeamID    string `parquet:"name=away_team_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	AwayTeamName  string `parquet:"name=away_team_name, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Stadium       string `parquet:"name=stadium, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Round         int32  `parquet:"name=round, type=INT32"`
+	Competition   string `parquet:"name=competition, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Country       string `parquet:"name=country, type=BYTE_ARRAY, convertedtype=UTF8"`
+	KickOff       int64  `parquet:"name=kick_off, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	UpdatedAt     int64  `parquet:"name=updated_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+	Version       int64  `parquet:"name=version, type=INT64"`
+}
+
+// FootballMatchEventParquetRow is the row of a football match event in the Parquet archive.
+// Timestamps are in Unix milliseconds.
+type FootballMatchEventParquetRow struct {
+	SchemaVersion int32   `parquet:"name=schema_version, type=INT32"`
+	ID            string  `parquet:"name=id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	MatchID       string  `parquet:"name=match_id, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Type          string  `parquet:"name=type, type=BYTE_ARRAY, convertedtype=UTF8"`
+	Minute        int32   `parquet:"name=minute, type=INT32"`
+	TeamName      *string `parquet:"name=team_name, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	Player        *string `parquet:"name=player, type=BYTE_ARRAY, convertedtype=UTF8, repetitiontype=OPTIONAL"`
+	OccurredAt    int64   `parquet:"name=occurred_at, type=INT64, convertedtype=TIMESTAMP_MILLIS"`
+}
+
+func (fm *FootballMatch) ToParquetRow() *FootballMatchParquetRow {
+	row := &FootballMatchParquetRow{
+		SchemaVersion: int32(fm.SchemaVersion),
+		ID:            fm.ID.String(),
+		Stadium:       fm.Stadium,
+		Round:         int32(fm.Round),
+		Competition:   fm.Competition,
+		Country:       fm.Country,
+		KickOff:       fm.KickOff.UnixMilli(),
+		UpdatedAt:     fm.UpdatedAt.UnixMilli(),
+		Version:       fm.Version(),
+	}
+
+	if fm.HomeTeam != nil {
+		row.HomeTeamID, row.HomeTeamName = fm.HomeTeam.ID.String(), fm.HomeTeam.Name
+	}
+
+	if fm.AwayTeam != nil {
+		row.AwayTeamID, row.AwayTeamName = fm.AwayTeam.ID.String(), fm.AwayTeam.Name
+	}
+
+	return row
+}
+
+func (ev *FootballMatchEvent) ToParquetRow() *FootballMatchEventParquetRow {
+	row := &FootballMatchEventParquetRow{
+		SchemaVersion: int32(ev.SchemaVersion),
+		ID:            ev.ID.String(),
+		MatchID:       ev.MatchID.String(),
+		Type:          string(ev.Type),
+		Minute:        int32(ev.Minute),
+		OccurredAt:    ev.OccurredAt.UnixMilli(),
+	}
+
+	if ev.Team != nil {
+		row.TeamName = &ev.Team.Name
+	}
+
+	if ev.Player != "" {
+		row.Player = &ev.Player
+	}
+
+	return row
+}
@@ -0,0 +1,134 @@
+package sports
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/tuannkhoi/sport-data-feed/sports/sportspb"
+)
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/tuannkhoi/sport-data-feed sports/v1/football.proto
+
+func (fm *FootballMatch) ToProto() *sportspb.FootballMatch {
+	return &sportspb.FootballMatch{
+		Id:          fm.ID[:],
+		HomeTeam:    fm.HomeTeam.ToProto(),
+		AwayTeam:    fm.AwayTeam.ToProto(),
+		Stadium:     fm.Stadium,
+		Round:       int32(fm.Round),
+		Competition: fm.Competition,
+		Country:     fm.Country,
+		KickOff:     timestamppb.New(fm.KickOff),
+		UpdatedAt:   timestamppb.New(fm.UpdatedAt),
+	}
+}
+
+func (ft *FootballTeam) ToProto() *sportspb.FootballTeam {
+	if ft == nil {
+		return nil
+	}
+
+	return &sportspb.FootballTeam{
+		Id:      ft.ID[:],
+		Name:    ft.Name,
+		Stadium: ft.Stadium,
+	}
+}
+
+// FootballMatchFromProto converts a Protobuf football match back into a FootballMatch.
+func FootballMatchFromProto(pb *sportspb.FootballMatch) (*FootballMatch, error) {
+	id, err := uuid.FromBytes(pb.GetId())
+	if err != nil {
+		return nil, errors.New("Invalid football match ID: " + err.Error())
+	}
+
+	homeTeam, err := FootballTeamFromProto(pb.GetHomeTeam())
+	if err != nil {
+		return nil, err
+	}
+
+	awayTeam, err := FootballTeamFromProto(pb.GetAwayTeam())
+	if err != nil {
+		return nil, err
+	}
+
+	fm := &FootballMatch{
+		SchemaVersion: FootballMatchSchemaVersion,
+		ID:            id,
+		HomeTeam:      homeTeam,
+		AwayTeam:      awayTeam,
+		Stadium:       pb.GetStadium(),
+		Round:         int(pb.GetRound()),
+		Competition:   pb.GetCompetition(),
+		Country:       pb.GetCountry(),
+		KickOff:       pb.GetKickOff().AsTime(),
+	}
+
+	// messages produced before updated_at existed leave it unset
+	if pb.GetUpdatedAt() != nil {
+		fm.UpdatedAt = pb.GetUpdatedAt().AsTime()
+	}
+
+	return fm, nil
+}
+
+// FootballTeamFromProto converts a Protobuf football team back into a FootballTeam.
+func FootballTeamFromProto(pb *sportspb.FootballTeam) (*FootballTeam, error) {
+	if pb == nil {
+		return nil, nil
+	}
+
+	id, err := uuid.FromBytes(pb.GetId())
+	if err != nil {
+		return nil, errors.New("Invalid football team ID: " + err.Error())
+	}
+
+	return &FootballTeam{
+		ID:      id,
+		Name:    pb.GetName(),
+		Stadium: pb.GetStadium(),
+	}, nil
+}
+
+func (ev *FootballMatchEvent) ToProto() *sportspb.FootballMatchEvent {
+	return &sportspb.FootballMatchEvent{
+		Id:         ev.ID[:],
+		MatchId:    ev.MatchID[:],
+		Type:       string(ev.Type),
+		Minute:     int32(ev.Minute),
+		Team:       ev.Team.ToProto(),
+		Player:     ev.Player,
+		OccurredAt: timestamppb.New(ev.OccurredAt),
+	}
+}
+
+// FootballMatchEventFromProto converts a Protobuf football match event back into a FootballMatchEvent.
+func FootballMatchEventFromProto(pb *sportspb.FootballMatchEvent) (*FootballMatchEvent, error) {
+	id, err := uuid.FromBytes(pb.GetId())
+	if err != nil {
+		return nil, errors.New("Invalid football match event ID: " + err.Error())
+	}
+
+	matchID, err := uuid.FromBytes(pb.GetMatchId())
+	if err != nil {
+		return nil, errors.New("Invalid football match ID: " + err.Error())
+	}
+
+	team, err := FootballTeamFromProto(pb.GetTeam())
+	if err != nil {
+		return nil, err
+	}
+
+	return &FootballMatchEvent{
+		SchemaVersion: FootballMatchEventSchemaVersion,
+		ID:            id,
+		MatchID:       matchID,
+		Type:          FootballMatchEventType(pb.GetType()),
+		Minute:        int(pb.GetMinute()),
+		Team:          team,
+		Player:        pb.GetPlayer(),
+		OccurredAt:    pb.GetOccurredAt().AsTime(),
+	}, nil
+}
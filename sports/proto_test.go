@@ -0,0 +1,56 @@
+package sports
+
+import (
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/tuannkhoi/sport-data-feed/sports/sportspb"
+)
+
+func TestFootballMatchProtoRoundTrip(t *testing.T) {
+	withoutAwayTeam := NewFootballMatch()
+	withoutAwayTeam.AwayTeam = nil
+
+	for _, fm := range []*FootballMatch{NewFootballMatch(), withoutAwayTeam} {
+		data, err := proto.Marshal(fm.ToProto())
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+
+		pb := new(sportspb.FootballMatch)
+		if err := proto.Unmarshal(data, pb); err != nil {
+			t.Fatalf("Unmarshal() error = %v", err)
+		}
+
+		got, err := FootballMatchFromProto(pb)
+		if err != nil {
+			t.Fatalf("FootballMatchFromProto() error = %v", err)
+		}
+
+		// Protobuf timestamps come back in UTC and without a monotonic reading
+		if !got.KickOff.Equal(fm.KickOff) {
+			t.Errorf("KickOff = %s, want %s", got.KickOff, fm.KickOff)
+		}
+
+		if !got.UpdatedAt.Equal(fm.UpdatedAt) {
+			t.Errorf("UpdatedAt = %s, want %s", got.UpdatedAt, fm.UpdatedAt)
+		}
+
+		got.KickOff, got.UpdatedAt = fm.KickOff, fm.UpdatedAt
+
+		if !reflect.DeepEqual(got, fm) {
+			t.Errorf("FootballMatchFromProto() = %+v, want %+v", got, fm)
+		}
+	}
+}
+
+func TestFootballMatchFromProtoInvalidID(t *testing.T) {
+	pb := NewFootballMatch().ToProto()
+	pb.Id = []byte("not a uuid")
+
+	if _, err := FootballMatchFromProto(pb); err == nil {
+		t.Error("FootballMatchFromProto() error = nil, want an error for an invalid ID")
+	}
+}
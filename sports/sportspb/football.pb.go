@@ -0,0 +1,452 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: sports/v1/football.proto
+
+package sportspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// FootballTeam is a club taking part in a competition.
+type FootballTeam struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the 16-byte binary form of the team UUID.
+	Id      []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name    string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Stadium string `protobuf:"bytes,3,opt,name=stadium,proto3" json:"stadium,omitempty"`
+}
+
+func (x *FootballTeam) Reset() {
+	*x = FootballTeam{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sports_v1_football_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FootballTeam) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FootballTeam) ProtoMessage() {}
+
+func (x *FootballTeam) ProtoReflect() protoreflect.Message {
+	mi := &file_sports_v1_football_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FootballTeam.ProtoReflect.Descriptor instead.
+func (*FootballTeam) Descriptor() ([]byte, []int) {
+	return file_sports_v1_football_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FootballTeam) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *FootballTeam) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *FootballTeam) GetStadium() string {
+	if x != nil {
+		return x.Stadium
+	}
+	return ""
+}
+
+// FootballMatch is published on the football-match-new topic whenever a fixture is created.
+type FootballMatch struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the 16-byte binary form of the match UUID.
+	Id          []byte                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	HomeTeam    *FootballTeam          `protobuf:"bytes,2,opt,name=home_team,json=homeTeam,proto3" json:"home_team,omitempty"`
+	AwayTeam    *FootballTeam          `protobuf:"bytes,3,opt,name=away_team,json=awayTeam,proto3" json:"away_team,omitempty"`
+	Stadium     string                 `protobuf:"bytes,4,opt,name=stadium,proto3" json:"stadium,omitempty"`
+	Round       int32                  `protobuf:"varint,5,opt,name=round,proto3" json:"round,omitempty"`
+	Competition string                 `protobuf:"bytes,6,opt,name=competition,proto3" json:"competition,omitempty"`
+	Country     string                 `protobuf:"bytes,7,opt,name=country,proto3" json:"country,omitempty"`
+	KickOff     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=kick_off,json=kickOff,proto3" json:"kick_off,omitempty"`
+	// updated_at is when this state of the match was published, ordering the writes of the same match.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+}
+
+func (x *FootballMatch) Reset() {
+	*x = FootballMatch{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sports_v1_football_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FootballMatch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FootballMatch) ProtoMessage() {}
+
+func (x *FootballMatch) ProtoReflect() protoreflect.Message {
+	mi := &file_sports_v1_football_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FootballMatch.ProtoReflect.Descriptor instead.
+func (*FootballMatch) Descriptor() ([]byte, []int) {
+	return file_sports_v1_football_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FootballMatch) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *FootballMatch) GetHomeTeam() *FootballTeam {
+	if x != nil {
+		return x.HomeTeam
+	}
+	return nil
+}
+
+func (x *FootballMatch) GetAwayTeam() *FootballTeam {
+	if x != nil {
+		return x.AwayTeam
+	}
+	return nil
+}
+
+func (x *FootballMatch) GetStadium() string {
+	if x != nil {
+		return x.Stadium
+	}
+	return ""
+}
+
+func (x *FootballMatch) GetRound() int32 {
+	if x != nil {
+		return x.Round
+	}
+	return 0
+}
+
+func (x *FootballMatch) GetCompetition() string {
+	if x != nil {
+		return x.Competition
+	}
+	return ""
+}
+
+func (x *FootballMatch) GetCountry() string {
+	if x != nil {
+		return x.Country
+	}
+	return ""
+}
+
+func (x *FootballMatch) GetKickOff() *timestamppb.Timestamp {
+	if x != nil {
+		return x.KickOff
+	}
+	return nil
+}
+
+func (x *FootballMatch) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// FootballMatchEvent is published on the football-match-event topic when something happens during, or to, a match.
+type FootballMatchEvent struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// id is the 16-byte binary form of the event UUID.
+	Id []byte `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// match_id is the 16-byte binary form of the UUID of the match the event belongs to.
+	MatchId []byte `protobuf:"bytes,2,opt,name=match_id,json=matchId,proto3" json:"match_id,omitempty"`
+	// type is one of kick_off, goal, yellow_card, red_card, half_time, full_time, abandoned or postponed.
+	Type       string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Minute     int32                  `protobuf:"varint,4,opt,name=minute,proto3" json:"minute,omitempty"`
+	Team       *FootballTeam          `protobuf:"bytes,5,opt,name=team,proto3" json:"team,omitempty"`
+	Player     string                 `protobuf:"bytes,6,opt,name=player,proto3" json:"player,omitempty"`
+	OccurredAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=occurred_at,json=occurredAt,proto3" json:"occurred_at,omitempty"`
+}
+
+func (x *FootballMatchEvent) Reset() {
+	*x = FootballMatchEvent{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_sports_v1_football_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *FootballMatchEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FootballMatchEvent) ProtoMessage() {}
+
+func (x *FootballMatchEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_sports_v1_football_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FootballMatchEvent.ProtoReflect.Descriptor instead.
+func (*FootballMatchEvent) Descriptor() ([]byte, []int) {
+	return file_sports_v1_football_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *FootballMatchEvent) GetId() []byte {
+	if x != nil {
+		return x.Id
+	}
+	return nil
+}
+
+func (x *FootballMatchEvent) GetMatchId() []byte {
+	if x != nil {
+		return x.MatchId
+	}
+	return nil
+}
+
+func (x *FootballMatchEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *FootballMatchEvent) GetMinute() int32 {
+	if x != nil {
+		return x.Minute
+	}
+	return 0
+}
+
+func (x *FootballMatchEvent) GetTeam() *FootballTeam {
+	if x != nil {
+		return x.Team
+	}
+	return nil
+}
+
+func (x *FootballMatchEvent) GetPlayer() string {
+	if x != nil {
+		return x.Player
+	}
+	return ""
+}
+
+func (x *FootballMatchEvent) GetOccurredAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.OccurredAt
+	}
+	return nil
+}
+
+var File_sports_v1_football_proto protoreflect.FileDescriptor
+
+var file_sports_v1_football_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x66, 0x6f, 0x6f, 0x74,
+	0x62, 0x61, 0x6c, 0x6c, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x4c, 0x0a, 0x0c, 0x46, 0x6f, 0x6f, 0x74, 0x62, 0x61,
+	0x6c, 0x6c, 0x54, 0x65, 0x61, 0x6d, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74,
+	0x61, 0x64, 0x69, 0x75, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x74, 0x61,
+	0x64, 0x69, 0x75, 0x6d, 0x22, 0xe9, 0x02, 0x0a, 0x0d, 0x46, 0x6f, 0x6f, 0x74, 0x62, 0x61, 0x6c,
+	0x6c, 0x4d, 0x61, 0x74, 0x63, 0x68, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01,
+	0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x34, 0x0a, 0x09, 0x68, 0x6f, 0x6d, 0x65, 0x5f, 0x74,
+	0x65, 0x61, 0x6d, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73, 0x70, 0x6f, 0x72,
+	0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6f, 0x6f, 0x74, 0x62, 0x61, 0x6c, 0x6c, 0x54, 0x65,
+	0x61, 0x6d, 0x52, 0x08, 0x68, 0x6f, 0x6d, 0x65, 0x54, 0x65, 0x61, 0x6d, 0x12, 0x34, 0x0a, 0x09,
+	0x61, 0x77, 0x61, 0x79, 0x5f, 0x74, 0x65, 0x61, 0x6d, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x17, 0x2e, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6f, 0x6f, 0x74,
+	0x62, 0x61, 0x6c, 0x6c, 0x54, 0x65, 0x61, 0x6d, 0x52, 0x08, 0x61, 0x77, 0x61, 0x79, 0x54, 0x65,
+	0x61, 0x6d, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x74, 0x61, 0x64, 0x69, 0x75, 0x6d, 0x18, 0x04, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x07, 0x73, 0x74, 0x61, 0x64, 0x69, 0x75, 0x6d, 0x12, 0x14, 0x0a, 0x05,
+	0x72, 0x6f, 0x75, 0x6e, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x72, 0x6f, 0x75,
+	0x6e, 0x64, 0x12, 0x20, 0x0a, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x65, 0x74, 0x69, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x65, 0x74, 0x69,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x18, 0x0a, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x63, 0x6f, 0x75, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x35,
+	0x0a, 0x08, 0x6b, 0x69, 0x63, 0x6b, 0x5f, 0x6f, 0x66, 0x66, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x6b, 0x69,
+	0x63, 0x6b, 0x4f, 0x66, 0x66, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64,
+	0x5f, 0x61, 0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65, 0x64, 0x41, 0x74,
+	0x22, 0xed, 0x01, 0x0a, 0x12, 0x46, 0x6f, 0x6f, 0x74, 0x62, 0x61, 0x6c, 0x6c, 0x4d, 0x61, 0x74,
+	0x63, 0x68, 0x45, 0x76, 0x65, 0x6e, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20,
+	0x01, 0x28, 0x0c, 0x52, 0x02, 0x69, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x61, 0x74, 0x63, 0x68,
+	0x5f, 0x69, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x6d, 0x61, 0x74, 0x63, 0x68,
+	0x49, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x74, 0x79, 0x70, 0x65, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x04, 0x74, 0x79, 0x70, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65,
+	0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x6d, 0x69, 0x6e, 0x75, 0x74, 0x65, 0x12, 0x2b,
+	0x0a, 0x04, 0x74, 0x65, 0x61, 0x6d, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x73,
+	0x70, 0x6f, 0x72, 0x74, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x46, 0x6f, 0x6f, 0x74, 0x62, 0x61, 0x6c,
+	0x6c, 0x54, 0x65, 0x61, 0x6d, 0x52, 0x04, 0x74, 0x65, 0x61, 0x6d, 0x12, 0x16, 0x0a, 0x06, 0x70,
+	0x6c, 0x61, 0x79, 0x65, 0x72, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x70, 0x6c, 0x61,
+	0x79, 0x65, 0x72, 0x12, 0x3b, 0x0a, 0x0b, 0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x64, 0x5f,
+	0x61, 0x74, 0x18, 0x07, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x0a, 0x6f, 0x63, 0x63, 0x75, 0x72, 0x72, 0x65, 0x64, 0x41, 0x74,
+	0x42, 0x36, 0x5a, 0x34, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x74,
+	0x75, 0x61, 0x6e, 0x6e, 0x6b, 0x68, 0x6f, 0x69, 0x2f, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x2d, 0x64,
+	0x61, 0x74, 0x61, 0x2d, 0x66, 0x65, 0x65, 0x64, 0x2f, 0x73, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x2f,
+	0x73, 0x70, 0x6f, 0x72, 0x74, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_sports_v1_football_proto_rawDescOnce sync.Once
+	file_sports_v1_football_proto_rawDescData = file_sports_v1_football_proto_rawDesc
+)
+
+func file_sports_v1_football_proto_rawDescGZIP() []byte {
+	file_sports_v1_football_proto_rawDescOnce.Do(func() {
+		file_sports_v1_football_proto_rawDescData = protoimpl.X.CompressGZIP(file_sports_v1_football_proto_rawDescData)
+	})
+	return file_sports_v1_football_proto_rawDescData
+}
+
+var file_sports_v1_football_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_sports_v1_football_proto_goTypes = []any{
+	(*FootballTeam)(nil),          // 0: sports.v1.FootballTeam
+	(*FootballMatch)(nil),         // 1: sports.v1.FootballMatch
+	(*FootballMatchEvent)(nil),    // 2: sports.v1.FootballMatchEvent
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_sports_v1_football_proto_depIdxs = []int32{
+	0, // 0: sports.v1.FootballMatch.home_team:type_name -> sports.v1.FootballTeam
+	0, // 1: sports.v1.FootballMatch.away_team:type_name -> sports.v1.FootballTeam
+	3, // 2: sports.v1.FootballMatch.kick_off:type_name -> google.protobuf.Timestamp
+	3, // 3: sports.v1.FootballMatch.updated_at:type_name -> google.protobuf.Timestamp
+	0, // 4: sports.v1.FootballMatchEvent.team:type_name -> sports.v1.FootballTeam
+	3, // 5: sports.v1.FootballMatchEvent.occurred_at:type_name -> google.protobuf.Timestamp
+	6, // [6:6] is the sub-list for method output_type
+	6, // [6:6] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_sports_v1_football_proto_init() }
+func file_sports_v1_football_proto_init() {
+	if File_sports_v1_football_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_sports_v1_football_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*FootballTeam); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sports_v1_football_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*FootballMatch); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_sports_v1_football_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*FootballMatchEvent); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_sports_v1_football_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_sports_v1_football_proto_goTypes,
+		DependencyIndexes: file_sports_v1_football_proto_depIdxs,
+		MessageInfos:      file_sports_v1_football_proto_msgTypes,
+	}.Build()
+	File_sports_v1_football_proto = out.File
+	file_sports_v1_football_proto_rawDesc = nil
+	file_sports_v1_football_proto_goTypes = nil
+	file_sports_v1_football_proto_depIdxs = nil
+}
@@ -0,0 +1,118 @@
+package sports
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// FootballTeamByName returns the team with the given name playing in the competition.
+func FootballTeamByName(competition, name string) (*FootballTeam, bool) {
+	for _, team := range teamsByLeague[competition] {
+		if team.Name == name {
+			return team, true
+		}
+	}
+
+	return nil, false
+}
+
+// Complete fills in the fields an externally supplied match may leave out:
+// a new ID, the registered teams looked up by name, the home stadium and the competition's country.
+func (fm *FootballMatch) Complete() {
+	fm.SchemaVersion = FootballMatchSchemaVersion
+
+	if fm.ID == uuid.Nil {
+		fm.ID = uuid.New()
+	}
+
+	fm.HomeTeam = completeFootballTeam(fm.Competition, fm.HomeTeam)
+	fm.AwayTeam = completeFootballTeam(fm.Competition, fm.AwayTeam)
+
+	if fm.Stadium == "" && fm.HomeTeam != nil {
+		fm.Stadium = fm.HomeTeam.Stadium
+	}
+
+	if fm.Country == "" {
+		fm.Country = countryByLeague[fm.Competition]
+	}
+
+	if fm.UpdatedAt.IsZero() {
+		fm.UpdatedAt = time.Now()
+	}
+}
+
+// completeFootballTeam swaps a team for the registered team of the same name, as team IDs are assigned by the feed.
+// Teams with an unknown name are left for Validate to report.
+func completeFootballTeam(competition string, team *FootballTeam) *FootballTeam {
+	if team == nil {
+		return nil
+	}
+
+	registered, ok := FootballTeamByName(competition, team.Name)
+	if !ok {
+		return team
+	}
+
+	return registered
+}
+
+// Validate checks the match against the competition rules and reports every violation at once.
+// Teams are matched by name, because team IDs are generated independently by every process.
+func (fm *FootballMatch) Validate() error {
+	teams, ok := teamsByLeague[fm.Competition]
+	if !ok {
+		return fmt.Errorf("unknown competition %q", fm.Competition)
+	}
+
+	var errs []error
+
+	if fm.ID == uuid.Nil {
+		errs = append(errs, errors.New("id is required"))
+	}
+
+	sides := []struct {
+		name string
+		team *FootballTeam
+	}{
+		{"home_team", fm.HomeTeam},
+		{"away_team", fm.AwayTeam},
+	}
+
+	for _, side := range sides {
+		team := side.team
+		if team == nil {
+			errs = append(errs, fmt.Errorf("%s is required", side.name))
+
+			continue
+		}
+
+		if _, ok := FootballTeamByName(fm.Competition, team.Name); !ok {
+			errs = append(errs, fmt.Errorf("%s %q does not play in %s", side.name, team.Name, fm.Competition))
+		}
+	}
+
+	if fm.HomeTeam != nil && fm.AwayTeam != nil && fm.HomeTeam.Name == fm.AwayTeam.Name {
+		errs = append(errs, errors.New("home_team and away_team must be different teams"))
+	}
+
+	if country := countryByLeague[fm.Competition]; fm.Country != country {
+		errs = append(errs, fmt.Errorf("country %q does not match %s, expected %q", fm.Country, fm.Competition, country))
+	}
+
+	if maxRound := len(teams) * 2; fm.Round < 1 || fm.Round > maxRound {
+		errs = append(errs, fmt.Errorf("round %d is out of range, %s has rounds 1 to %d", fm.Round, fm.Competition, maxRound))
+	}
+
+	if fm.Stadium == "" {
+		errs = append(errs, errors.New("stadium is required"))
+	}
+
+	if fm.KickOff.IsZero() {
+		errs = append(errs, errors.New("kick_off is required"))
+	}
+
+	return errors.Join(errs...)
+}
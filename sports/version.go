@@ -0,0 +1,67 @@
+package sports
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FootballMatchSchemaVersion is the version of the FootballMatch JSON contract produced by this build.
+// Bump it whenever the JSON shape of FootballMatch changes, register an upcaster from the previous
+// version below, and regenerate the JSON Schema with `go generate ./schemas`.
+//
+// Version history:
+//   - 1: initial contract, payloads carry no schema_version field.
+//   - 2: adds schema_version.
+//   - 3: adds the optional updated_at, which orders the writes of the same match.
+const FootballMatchSchemaVersion = 3
+
+// Upcaster rewrites a decoded event of one schema version into the shape of the next version.
+type Upcaster func(event map[string]any) error
+
+// footballMatchUpcasters maps each old schema version to the upcaster that lifts it one version up.
+var footballMatchUpcasters = map[int]Upcaster{
+	1: func(event map[string]any) error {
+		event["schema_version"] = 2
+
+		return nil
+	},
+	// older payloads carry no updated_at, consumers fall back to the time the message was produced
+	2: func(event map[string]any) error {
+		event["schema_version"] = 3
+
+		return nil
+	},
+}
+
+// UpcastFootballMatch rewrites a FootballMatch JSON payload of any older schema version into the
+// current version. Payloads that are already current, or newer, are returned unchanged.
+func UpcastFootballMatch(data []byte) ([]byte, error) {
+	event := make(map[string]any)
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	version := 1
+
+	if v, ok := event["schema_version"].(float64); ok {
+		version = int(v)
+	}
+
+	if version >= FootballMatchSchemaVersion {
+		return data, nil
+	}
+
+	for ; version < FootballMatchSchemaVersion; version++ {
+		upcast, ok := footballMatchUpcasters[version]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster from football match schema version %d", version)
+		}
+
+		if err := upcast(event); err != nil {
+			return nil, fmt.Errorf("Failed to upcast football match from schema version %d: %w", version, err)
+		}
+	}
+
+	return json.Marshal(event)
+}